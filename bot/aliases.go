@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// AliasNotFoundError Returned when removing an alias a channel doesn't have
+type AliasNotFoundError struct {
+	Alias string
+}
+
+func (e *AliasNotFoundError) Error() string {
+	return fmt.Sprintf("No alias %s found", e.Alias)
+}
+
+// InvalidAliasError Returned when an alias can't be stored as a key of the
+// channel's aliases
+type InvalidAliasError struct {
+	Alias string
+}
+
+func (e *InvalidAliasError) Error() string {
+	return fmt.Sprintf("Invalid alias %s, aliases can't contain %s", e.Alias, aliasReservedChars)
+}
+
+// aliasReservedChars Characters DynamoDB reads as part of an attribute path, so
+// they can't be in a single map key
+const aliasReservedChars = ".[]"
+
+// AddAlias Adds an alias for skill to the channel, replacing any alias of the same
+// name. Only the alias is written, so aliases changed at the same time are kept
+func (bot *OziachBot) AddAlias(name, alias string, skill Skill) (Channel, error) {
+	alias = strings.ToLower(alias)
+	if strings.ContainsAny(alias, aliasReservedChars) {
+		return Channel{}, &InvalidAliasError{alias}
+	}
+
+	channel, err := bot.ChannelDB.GetChannel(name)
+	if err != nil {
+		return Channel{}, err
+	}
+
+	// A single alias can only be set within an existing map
+	if channel.Aliases == nil {
+		builder := expression.NewBuilder().WithUpdate(
+			expression.Set(expression.Name("aliases"), expression.IfNotExists(
+				expression.Name("aliases"),
+				expression.Value(emptyMap{}),
+			)),
+		)
+
+		if _, err := bot.ChannelDB.UpdateChannel(name, builder); err != nil {
+			return Channel{}, err
+		}
+	}
+
+	builder := expression.NewBuilder().WithUpdate(
+		expression.Set(expression.Name("aliases."+alias), expression.Value(skillNames[skill])),
+	)
+
+	log.Printf("Attempting to alias %s to %s in channel %s", alias, skillNames[skill], name)
+	return bot.ChannelDB.UpdateChannel(name, builder)
+}
+
+// emptyMap Marshals to an empty DynamoDB map, where an empty Go map would be
+// marshalled to null
+type emptyMap struct{}
+
+func (emptyMap) MarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+	av.M = map[string]*dynamodb.AttributeValue{}
+	return nil
+}
+
+// RemoveAlias Removes an alias from the channel. Only the alias is removed, so
+// aliases changed at the same time are kept
+func (bot *OziachBot) RemoveAlias(name, alias string) (Channel, error) {
+	channel, err := bot.ChannelDB.GetChannel(name)
+	if err != nil {
+		return Channel{}, err
+	}
+
+	alias = strings.ToLower(alias)
+	if _, ok := channel.Aliases[alias]; !ok {
+		return Channel{}, &AliasNotFoundError{alias}
+	}
+
+	builder := expression.NewBuilder().WithUpdate(
+		expression.Remove(expression.Name("aliases." + alias)),
+	)
+
+	log.Printf("Attempting to remove alias %s from channel %s", alias, name)
+	return bot.ChannelDB.UpdateChannel(name, builder)
+}
+
+// HandleAliasAdd Adds an alias for a skill to the channel, replying with the skill
+// it now maps to
+func (bot *OziachBot) HandleAliasAdd(channel, user, alias, skillName string) error {
+	// Aliases can't be chained, so the skill is matched without the channel's own
+	skill, err := bot.matchSkill(channel, user, skillName, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = bot.AddAlias(channel, alias, skill)
+
+	switch err.(type) {
+	case nil:
+		bot.Say(channel, fmt.Sprintf("@%s %s now looks up %s", user, strings.ToLower(alias), skillNames[skill]))
+	case *InvalidAliasError:
+		bot.Say(channel, fmt.Sprintf("@%s Aliases can't contain %s", user, aliasReservedChars))
+	default:
+		log.Printf("Could not add alias %s: %s", alias, err)
+		bot.Say(channel, fmt.Sprintf("@%s Could not add alias %s, try again later", user, strings.ToLower(alias)))
+	}
+
+	return err
+}
+
+// HandleAliasRemove Removes an alias from the channel
+func (bot *OziachBot) HandleAliasRemove(channel, user, alias string) error {
+	_, err := bot.RemoveAlias(channel, alias)
+
+	switch err.(type) {
+	case nil:
+		bot.Say(channel, fmt.Sprintf("@%s Removed alias %s", user, strings.ToLower(alias)))
+	case *AliasNotFoundError:
+		bot.Say(channel, fmt.Sprintf("@%s This channel has no alias %s", user, strings.ToLower(alias)))
+	default:
+		log.Printf("Could not remove alias %s: %s", alias, err)
+		bot.Say(channel, fmt.Sprintf("@%s Could not remove alias %s, try again later", user, strings.ToLower(alias)))
+	}
+
+	return err
+}
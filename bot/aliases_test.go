@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc"
+)
+
+func TestAliases(t *testing.T) {
+	bot := NewMockBot()
+	channelDB := newStoredChannelDB(Channel{
+		Name:        "channel",
+		IsConnected: true,
+		RSN:         ironmanAccount,
+		Aliases:     map[string]string{"kc": "Slayer"},
+	})
+	bot.ChannelDB = channelDB
+
+	moderator := twitch.User{
+		Username:    "moderator",
+		DisplayName: "Moderator",
+		Badges:      map[string]int{"moderator": 1},
+	}
+	viewer := twitch.User{
+		Username:    "viewer",
+		DisplayName: "Viewer",
+	}
+
+	say := func(user twitch.User, text string) string {
+		go bot.HandleMessage("channel", user, twitch.Message{Text: text})
+
+		select {
+		case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+			return resp
+		case <-time.After(3 * time.Second):
+			t.Fatal("Message handling unsuccessful due to timeout")
+			return ""
+		}
+	}
+
+	t.Run("ViewerAdd", func(t *testing.T) {
+		bot.HandleMessage("channel", viewer, twitch.Message{Text: "!alias add bowman ranged"})
+
+		select {
+		case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+			t.Errorf("Bot responded with %s, but only mods can add aliases", resp)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		expected := "/me @Moderator bowman now looks up Ranged"
+
+		if resp := say(moderator, "!alias add Bowman rangd"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+
+		expectedAliases := map[string]string{"kc": "Slayer", "bowman": "Ranged"}
+		if actual := channelDB.channel("channel"); !reflect.DeepEqual(actual.Aliases, expectedAliases) {
+			t.Errorf("Expected aliases %v, found %v", expectedAliases, actual.Aliases)
+		}
+	})
+
+	t.Run("Lookup", func(t *testing.T) {
+		expected := "/me " + FormatSkillLookupOutput(
+			viewer.DisplayName,
+			ironmanAccount,
+			"Ranged",
+			GameModeIronman,
+			SkillHiscore{
+				Rank:  342695,
+				Level: 90,
+				Exp:   5866885,
+			},
+			ChannelSettings{},
+		)
+
+		if resp := say(viewer, "!lvl bowman"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		expected := "/me @Moderator Removed alias bowman"
+
+		if resp := say(moderator, "!alias remove bowman"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+
+		expectedAliases := map[string]string{"kc": "Slayer"}
+		if actual := channelDB.channel("channel"); !reflect.DeepEqual(actual.Aliases, expectedAliases) {
+			t.Errorf("Expected aliases %v, found %v", expectedAliases, actual.Aliases)
+		}
+	})
+
+	t.Run("InvalidAlias", func(t *testing.T) {
+		expected := "/me @Moderator Aliases can't contain .[]"
+
+		if resp := say(moderator, "!alias add bow.man ranged"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("RemoveMissing", func(t *testing.T) {
+		expected := "/me @Moderator This channel has no alias bowman"
+
+		if resp := say(moderator, "!alias remove bowman"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+}
+
+func TestAddAliasConcurrently(t *testing.T) {
+	bot := NewMockBot()
+	channelDB := newStoredChannelDB(Channel{Name: "channel", IsConnected: true})
+	bot.ChannelDB = channelDB
+
+	aliases := map[string]Skill{"bowman": SkillRanged, "chop": SkillWoodcutting, "lumber": SkillWoodcutting}
+
+	var wg sync.WaitGroup
+	for alias, skill := range aliases {
+		wg.Add(1)
+		go func(alias string, skill Skill) {
+			defer wg.Done()
+
+			if _, err := bot.AddAlias("channel", alias, skill); err != nil {
+				t.Error(err)
+			}
+		}(alias, skill)
+	}
+	wg.Wait()
+
+	// Every alias is kept, however the updates interleave
+	expected := map[string]string{"bowman": "Ranged", "chop": "Woodcutting", "lumber": "Woodcutting"}
+	if actual := channelDB.channel("channel"); !reflect.DeepEqual(actual.Aliases, expected) {
+		t.Errorf("Expected aliases %v, found %v", expected, actual.Aliases)
+	}
+}
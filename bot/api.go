@@ -151,8 +151,11 @@ func (bot *OziachBot) APIChangeRSN(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			code := http.StatusInternalServerError
-			if _, ok := err.(ChannelNotFoundError); ok {
+			switch err.(type) {
+			case ChannelNotFoundError:
 				code = http.StatusNotFound
+			case *InvalidRSNError:
+				code = http.StatusBadRequest
 			}
 			HTTPError(w, err, code)
 		}
@@ -161,6 +164,138 @@ func (bot *OziachBot) APIChangeRSN(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// APIChangeSettings Endpoint handler function to route to ChangeSettings. Settings
+// missing from the JSON body keep their current value. Cooldowns in the body are
+// merged into the current ones, where an empty cooldown removes one
+func (bot *OziachBot) APIChangeSettings(w http.ResponseWriter, r *http.Request) {
+	pathParams := mux.Vars(r)
+	w.Header().Set("Content-Type", "application/json")
+
+	if name, ok := pathParams["channel"]; ok {
+		channel, err := bot.ChannelDB.GetChannel(name)
+
+		if err != nil {
+			HTTPError(w, err, http.StatusNotFound)
+			return
+		}
+
+		// Cooldowns are copied so the decoded ones are merged into a map of its own
+		settings := channel.Settings
+		settings.Cooldowns = bot.knownCooldowns(channel)
+
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			HTTPError(w, fmt.Sprintf("Bad request body: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		channel, err = bot.ChangeSettings(name, settings)
+
+		if err != nil {
+			code := http.StatusInternalServerError
+			switch err.(type) {
+			case ChannelNotFoundError:
+				code = http.StatusNotFound
+			case *UnknownCommandError, *InvalidCooldownError:
+				code = http.StatusBadRequest
+			}
+			HTTPError(w, err, code)
+		} else {
+			json, err := json.Marshal(channel)
+
+			if err != nil {
+				HTTPError(w, err, http.StatusInternalServerError)
+			} else {
+				w.Write(json)
+			}
+		}
+	} else {
+		HTTPError(w, "Bad request format: /channel/{channel}/settings required", http.StatusBadRequest)
+	}
+}
+
+// APIChangePermissions Endpoint handler function to route to ChangePermissions.
+// The JSON body maps commands to permissions, where an empty permission removes
+// the override
+func (bot *OziachBot) APIChangePermissions(w http.ResponseWriter, r *http.Request) {
+	pathParams := mux.Vars(r)
+	w.Header().Set("Content-Type", "application/json")
+
+	if name, ok := pathParams["channel"]; ok {
+		overrides := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&overrides); err != nil {
+			HTTPError(w, fmt.Sprintf("Bad request body: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		channel, err := bot.ChangePermissions(name, overrides)
+
+		if err != nil {
+			code := http.StatusInternalServerError
+			switch err.(type) {
+			case ChannelNotFoundError:
+				code = http.StatusNotFound
+			case *UnknownCommandError, *UnknownPermissionError:
+				code = http.StatusBadRequest
+			}
+			HTTPError(w, err, code)
+		} else {
+			json, err := json.Marshal(channel)
+
+			if err != nil {
+				HTTPError(w, err, http.StatusInternalServerError)
+			} else {
+				w.Write(json)
+			}
+		}
+	} else {
+		HTTPError(w, "Bad request format: /channel/{channel}/permissions required", http.StatusBadRequest)
+	}
+}
+
+// HiscoreStatus Status of the layers wrapping the Hiscore API client, as reported by
+// APIHiscoreStatus. Layers that aren't in use are omitted
+type HiscoreStatus struct {
+	Breaker *BreakerStatus `json:"breaker,omitempty"`
+	Cache   *CacheStats    `json:"cache,omitempty"`
+}
+
+// GetHiscoreStatus Collects the status of every layer wrapping the Hiscore API client
+func (bot *OziachBot) GetHiscoreStatus() HiscoreStatus {
+	status := HiscoreStatus{}
+	client := bot.HiscoreAPI.Client
+
+	for client != nil {
+		switch layer := client.(type) {
+		case *CircuitBreakerHiscoreAPIClient:
+			breakerStatus := layer.Status()
+			status.Breaker = &breakerStatus
+		case *CachingHiscoreAPIClient:
+			cacheStats := layer.Stats()
+			status.Cache = &cacheStats
+		}
+
+		wrapper, ok := client.(interface{ Unwrap() HiscoreAPIClient })
+		if !ok {
+			break
+		}
+		client = wrapper.Unwrap()
+	}
+
+	return status
+}
+
+// APIHiscoreStatus Endpoint handler function to route to GetHiscoreStatus
+func (bot *OziachBot) APIHiscoreStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json, err := json.Marshal(bot.GetHiscoreStatus())
+
+	if err != nil {
+		HTTPError(w, err, http.StatusInternalServerError)
+	} else {
+		w.Write(json)
+	}
+}
+
 // Heartbeat Returns "ok" to validate the health of the application
 func Heartbeat(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain")
@@ -177,13 +312,17 @@ func (bot *OziachBot) ServeAPI() {
 	obRouter := router.PathPrefix("/oziachbot").Subrouter()
 	channelAPI := obRouter.PathPrefix("/channel").Subrouter()
 	connectAPI := obRouter.PathPrefix("/connect").Subrouter()
+	hiscoreAPI := obRouter.PathPrefix("/hiscores").Subrouter()
 
 	// Configure all endpoints in the channel API
 	channelAPI.HandleFunc("/{channel}", bot.APIGetChannel).Methods(http.MethodGet)
 	channelAPI.HandleFunc("/{channel}", bot.APIAddChannel).Methods(http.MethodPost)
 	channelAPI.HandleFunc("/{channel}/rsn/{rsn}", bot.APIChangeRSN).Methods(http.MethodPut)
+	channelAPI.HandleFunc("/{channel}/settings", bot.APIChangeSettings).Methods(http.MethodPut)
+	channelAPI.HandleFunc("/{channel}/permissions", bot.APIChangePermissions).Methods(http.MethodPut)
 	connectAPI.HandleFunc("/{channel}", bot.APIConnectToChannel).Methods(http.MethodPost)
 	connectAPI.HandleFunc("/{channel}", bot.APIDisconnectFromChannel).Methods(http.MethodDelete)
+	hiscoreAPI.HandleFunc("/status", bot.APIHiscoreStatus).Methods(http.MethodGet)
 
 	log.Fatal(http.ListenAndServe(":7373", router))
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -331,6 +332,255 @@ func TestAPIDisconnectFromChannel(t *testing.T) {
 	})
 }
 
+func TestAPIChangeRSN(t *testing.T) {
+	type testCase struct {
+		Name           string
+		Channel        string
+		RSN            string
+		ExpectedStatus int
+		ExpectedWrite  []byte
+	}
+
+	bot := NewMockBot()
+	channelDB := newStoredChannelDB(Channel{Name: "channel", RSN: "Zezima"})
+	bot.ChannelDB = channelDB
+
+	_, invalidErr := ParseRSN("Not.An.RSN")
+
+	testCases := []testCase{
+		testCase{
+			Name:           "ValidChannel",
+			Channel:        "channel",
+			RSN:            "Lynx_Titan",
+			ExpectedStatus: http.StatusOK,
+			ExpectedWrite:  []byte{},
+		},
+		testCase{
+			Name:           "InvalidChannel",
+			Channel:        "not a channel",
+			RSN:            "Lynx Titan",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedWrite:  JSONMessage(ChannelNotFoundError{"not a channel"}.Error()),
+		},
+		testCase{
+			Name:           "InvalidRSN",
+			Channel:        "channel",
+			RSN:            "Not.An.RSN",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedWrite:  JSONMessage(invalidErr.Error()),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, "", nil)
+			req = mux.SetURLVars(req, map[string]string{
+				"channel": tc.Channel,
+				"rsn":     tc.RSN,
+			})
+			respWriter := NewMockResponseWriter()
+			bot.APIChangeRSN(respWriter, req)
+
+			if respWriter.statusCode != tc.ExpectedStatus {
+				t.Errorf("Expected status code %v, but found %v", tc.ExpectedStatus, respWriter.statusCode)
+			}
+
+			if !bytes.Equal(respWriter.response, tc.ExpectedWrite) {
+				t.Errorf("Expected response %s, but found %s", tc.ExpectedWrite, respWriter.response)
+			}
+		})
+	}
+
+	// Only the valid RSN is stored
+	if rsn := channelDB.channel("channel").RSN; rsn != "Lynx Titan" {
+		t.Errorf("Expected RSN Lynx Titan to be stored, found %s", rsn)
+	}
+}
+
+func TestAPIChangeSettings(t *testing.T) {
+	type testCase struct {
+		Name           string
+		Channel        string
+		Body           string
+		ExpectedStatus int
+		ExpectedWrite  []byte
+	}
+
+	bot := NewMockBot()
+	initial := Channel{Name: "channel", Settings: ChannelSettings{
+		VirtualLevels: true,
+		Cooldowns:     map[string]Cooldown{"lvl": Cooldown{User: 10}, "stats": Cooldown{Channel: 30}},
+	}}
+	channelDB := newStoredChannelDB(initial)
+	bot.ChannelDB = channelDB
+
+	// Settings left out of the body are kept, and cooldowns are merged
+	updated := Channel{Name: "channel", Settings: ChannelSettings{
+		VirtualLevels: true,
+		Announcements: true,
+		Cooldowns:     map[string]Cooldown{"lvl": Cooldown{User: 20}, "compare": Cooldown{Argument: 60}},
+	}}
+	expectedUpdate, _ := json.Marshal(updated)
+
+	testCases := []testCase{
+		testCase{
+			Name:           "PartialBody",
+			Channel:        "channel",
+			Body:           `{"announcements": true, "cooldowns": {"lvl": {"user": 20}, "stats": {}, "compare": {"argument": 60}}}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedWrite:  expectedUpdate,
+		},
+		testCase{
+			Name:           "InvalidChannel",
+			Channel:        "not a channel",
+			Body:           `{"announcements": true}`,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedWrite:  JSONMessage(ChannelNotFoundError{"not a channel"}.Error()),
+		},
+		testCase{
+			Name:           "BadJSON",
+			Channel:        "channel",
+			Body:           `{"announcements": `,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedWrite:  JSONMessage("Bad request body: unexpected EOF"),
+		},
+		testCase{
+			Name:           "UnknownCommand",
+			Channel:        "channel",
+			Body:           `{"cooldowns": {"sailing": {"user": 10}}}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedWrite:  JSONMessage((&UnknownCommandError{"sailing"}).Error()),
+		},
+		testCase{
+			Name:           "NegativeCooldown",
+			Channel:        "channel",
+			Body:           `{"cooldowns": {"lvl": {"user": -10}}}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedWrite:  JSONMessage((&InvalidCooldownError{"lvl", Cooldown{User: -10}}).Error()),
+		},
+	}
+
+	for _, tc := range testCases {
+		channelDB.put(initial)
+
+		t.Run(tc.Name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, "", strings.NewReader(tc.Body))
+			req = mux.SetURLVars(req, map[string]string{
+				"channel": tc.Channel,
+			})
+			respWriter := NewMockResponseWriter()
+			bot.APIChangeSettings(respWriter, req)
+
+			if respWriter.statusCode != tc.ExpectedStatus {
+				t.Errorf("Expected status code %v, but found %v", tc.ExpectedStatus, respWriter.statusCode)
+			}
+
+			if !bytes.Equal(respWriter.response, tc.ExpectedWrite) {
+				t.Errorf("Expected response %s, but found %s", tc.ExpectedWrite, respWriter.response)
+			}
+		})
+	}
+}
+
+func TestAPIChangePermissions(t *testing.T) {
+	type testCase struct {
+		Name           string
+		Channel        string
+		Body           string
+		ExpectedStatus int
+		ExpectedWrite  []byte
+	}
+
+	bot := NewMockBot()
+	initial := Channel{Name: "channel", Permissions: map[string]string{"stats": "subscriber"}}
+	channelDB := newStoredChannelDB(initial)
+	bot.ChannelDB = channelDB
+
+	updated := Channel{Name: "channel", Permissions: map[string]string{"lvl": "vip"}}
+	expectedUpdate, _ := json.Marshal(updated)
+
+	testCases := []testCase{
+		testCase{
+			Name:           "ValidChannel",
+			Channel:        "channel",
+			Body:           `{"!level": "VIP", "stats": ""}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedWrite:  expectedUpdate,
+		},
+		testCase{
+			Name:           "InvalidChannel",
+			Channel:        "not a channel",
+			Body:           `{"lvl": "vip"}`,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedWrite:  JSONMessage(ChannelNotFoundError{"not a channel"}.Error()),
+		},
+		testCase{
+			Name:           "UnknownCommand",
+			Channel:        "channel",
+			Body:           `{"sailing": "vip"}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedWrite:  JSONMessage((&UnknownCommandError{"sailing"}).Error()),
+		},
+		testCase{
+			Name:           "UnknownPermission",
+			Channel:        "channel",
+			Body:           `{"lvl": "admin"}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedWrite:  JSONMessage((&UnknownPermissionError{"admin"}).Error()),
+		},
+	}
+
+	for _, tc := range testCases {
+		channelDB.put(initial)
+
+		t.Run(tc.Name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, "", strings.NewReader(tc.Body))
+			req = mux.SetURLVars(req, map[string]string{
+				"channel": tc.Channel,
+			})
+			respWriter := NewMockResponseWriter()
+			bot.APIChangePermissions(respWriter, req)
+
+			if respWriter.statusCode != tc.ExpectedStatus {
+				t.Errorf("Expected status code %v, but found %v", tc.ExpectedStatus, respWriter.statusCode)
+			}
+
+			if !bytes.Equal(respWriter.response, tc.ExpectedWrite) {
+				t.Errorf("Expected response %s, but found %s", tc.ExpectedWrite, respWriter.response)
+			}
+		})
+	}
+}
+
+func TestAPIHiscoreStatus(t *testing.T) {
+	bot := NewMockBot()
+	breaker := NewCircuitBreakerHiscoreAPIClient(bot.HiscoreAPI.Client, 5, time.Minute)
+	cache := NewCachingHiscoreAPIClient(breaker, time.Minute, 10)
+	bot.HiscoreAPI = &HiscoreAPI{Client: cache}
+	bot.HiscoreAPI.LookupHiscoresByGameMode(normalAccount, GameModeNormal)
+
+	req, _ := http.NewRequest(http.MethodGet, "", nil)
+	respWriter := NewMockResponseWriter()
+	bot.APIHiscoreStatus(respWriter, req)
+
+	if respWriter.statusCode != http.StatusOK {
+		t.Errorf("Expected status code %v, but found %v", http.StatusOK, respWriter.statusCode)
+	}
+
+	status := HiscoreStatus{}
+	if err := json.Unmarshal(respWriter.response, &status); err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Breaker == nil || status.Breaker.State != BreakerClosed.String() {
+		t.Errorf("Expected closed breaker status, found %s", respWriter.response)
+	}
+
+	if status.Cache == nil || status.Cache.Misses != 1 {
+		t.Errorf("Expected cache status with 1 miss, found %s", respWriter.response)
+	}
+}
+
 func TestHeartbeat(t *testing.T) {
 	respWriter := NewMockResponseWriter()
 	req, _ := http.NewRequest(http.MethodGet, "", nil)
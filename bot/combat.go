@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"sort"
+
+	"github.com/mfboulos/oziachbot/xp"
+)
+
+// MaxCombatLevel Highest combat level a player can have
+const MaxCombatLevel = 126
+
+// combatSkills Skills that count towards combat level, in the order they're reported
+var combatSkills = []Skill{
+	SkillAttack,
+	SkillStrength,
+	SkillDefense,
+	SkillHitpoints,
+	SkillPrayer,
+	SkillRanged,
+	SkillMagic,
+}
+
+// CombatStyle Enum value for the combat style driving a combat level
+type CombatStyle int
+
+// Enumerated values for combat styles
+const (
+	CombatMelee CombatStyle = iota
+	CombatRanged
+	CombatMagic
+)
+
+func (style CombatStyle) String() string {
+	switch style {
+	case CombatMelee:
+		return "Melee"
+	case CombatRanged:
+		return "Ranged"
+	case CombatMagic:
+		return "Magic"
+	default:
+		return "Unknown"
+	}
+}
+
+// CombatLevel Combat level of a player, along with the style driving it
+type CombatLevel struct {
+	Level int
+	Style CombatStyle
+}
+
+// SkillRequirement Number of levels needed in a skill to reach a goal
+type SkillRequirement struct {
+	Skill  Skill
+	Levels int
+}
+
+// CombatLevel Calculates the player's combat level from their combat skills
+func (hiscores Hiscores) CombatLevel() CombatLevel {
+	return combatLevel(hiscores.combatSkillLevels())
+}
+
+// NextCombatLevel Returns how many levels are needed in each combat skill, on its
+// own, for the next combat level, fewest levels first. Skills that can't reach it
+// before 99 are left out, and nothing is returned at MaxCombatLevel
+func (hiscores Hiscores) NextCombatLevel() []SkillRequirement {
+	levels := hiscores.combatSkillLevels()
+	current := combatLevel(levels).Level
+	requirements := []SkillRequirement{}
+
+	if current >= MaxCombatLevel {
+		return requirements
+	}
+
+	for _, skill := range combatSkills {
+		trained := make(map[Skill]int, len(levels))
+		for s, level := range levels {
+			trained[s] = level
+		}
+
+		for trained[skill] < xp.MaxRealLevel {
+			trained[skill]++
+
+			if combatLevel(trained).Level > current {
+				requirements = append(requirements, SkillRequirement{skill, trained[skill] - levels[skill]})
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(requirements, func(i, j int) bool {
+		return requirements[i].Levels < requirements[j].Levels
+	})
+
+	return requirements
+}
+
+// combatSkillLevels Returns the level of every combat skill. Unranked skills are
+// reported below their starting level, which is 10 for Hitpoints and 1 otherwise
+func (hiscores Hiscores) combatSkillLevels() map[Skill]int {
+	levels := make(map[Skill]int, len(combatSkills))
+
+	for _, skill := range combatSkills {
+		level := hiscores.skills[skill].Level
+		if skill == SkillHitpoints && level < 10 {
+			level = 10
+		} else if level < 1 {
+			level = 1
+		}
+
+		levels[skill] = level
+	}
+
+	return levels
+}
+
+// combatLevel Calculates combat level with the formula used by the game:
+//
+// floor(0.25 * (Defense + Hitpoints + floor(Prayer / 2)) + 0.325 * max(Attack +
+// Strength, floor(1.5 * Ranged), floor(1.5 * Magic)))
+//
+// scaled by 40 to stay in integers
+func combatLevel(levels map[Skill]int) CombatLevel {
+	base := 10 * (levels[SkillDefense] + levels[SkillHitpoints] + levels[SkillPrayer]/2)
+
+	style, offense := CombatMelee, levels[SkillAttack]+levels[SkillStrength]
+	if ranged := 3 * levels[SkillRanged] / 2; ranged > offense {
+		style, offense = CombatRanged, ranged
+	}
+	if magic := 3 * levels[SkillMagic] / 2; magic > offense {
+		style, offense = CombatMagic, magic
+	}
+
+	return CombatLevel{
+		Level: (base + 13*offense) / 40,
+		Style: style,
+	}
+}
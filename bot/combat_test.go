@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newCombatHiscores Returns Hiscores with the given combat skill levels, in the
+// order of combatSkills
+func newCombatHiscores(levels ...int) Hiscores {
+	hiscores := newHiscores()
+
+	for i, skill := range combatSkills {
+		hiscores.skills[skill] = SkillHiscore{Rank: 1, Level: levels[i]}
+	}
+
+	return hiscores
+}
+
+func TestCombatLevel(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Hiscores Hiscores
+		Expected CombatLevel
+	}{
+		{"Mock", newCombatHiscores(50, 90, 1, 86, 45, 90, 96), CombatLevel{74, CombatMagic}},
+		{"Fresh", newCombatHiscores(1, 1, 1, 10, 1, 1, 1), CombatLevel{3, CombatMelee}},
+		{"Unranked", newCombatHiscores(1, 1, 1, 1, 1, 1, 1), CombatLevel{3, CombatMelee}},
+		{"Ranged", newCombatHiscores(1, 1, 1, 99, 1, 99, 1), CombatLevel{73, CombatRanged}},
+		{"Maxed", newCombatHiscores(99, 99, 99, 99, 99, 99, 99), CombatLevel{126, CombatMelee}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			if actual := tc.Hiscores.CombatLevel(); actual != tc.Expected {
+				t.Errorf("Expected %+v, got %+v", tc.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestNextCombatLevel(t *testing.T) {
+	t.Run("Mock", func(t *testing.T) {
+		hiscores := newCombatHiscores(50, 90, 1, 86, 45, 90, 96)
+		expected := []SkillRequirement{
+			{SkillMagic, 2},
+			{SkillDefense, 4},
+			{SkillHitpoints, 4},
+			{SkillAttack, 7},
+			{SkillStrength, 7},
+			{SkillPrayer, 7},
+			{SkillRanged, 8},
+		}
+
+		if actual := hiscores.NextCombatLevel(); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, actual)
+		}
+	})
+
+	t.Run("Maxed", func(t *testing.T) {
+		hiscores := newCombatHiscores(99, 99, 99, 99, 99, 99, 99)
+
+		if actual := hiscores.NextCombatLevel(); len(actual) != 0 {
+			t.Errorf("Expected no requirements, got %+v", actual)
+		}
+	})
+}
@@ -2,8 +2,14 @@ package bot
 
 import (
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/mfboulos/oziachbot/xp"
 )
 
 // IncorrectFormatError Returned when a command invocation is malformed
@@ -13,30 +19,74 @@ func (e *IncorrectFormatError) Error() string {
 	return "Incorrect format for command"
 }
 
+// FormatLookupFailureOutput Formats the reply sent by OziachBot when a hiscore
+// lookup fails, based on the reason it failed
+func FormatLookupFailureOutput(user, player string, err error) string {
+	if isHiscoreOutage(err) {
+		return fmt.Sprintf("@%s The OSRS hiscores are unavailable right now, try again later", user)
+	}
+
+	switch err.(type) {
+	case *HiscoreLayoutError:
+		return fmt.Sprintf("@%s Could not read hiscores for %s: hiscore layout changed", user, player)
+	default:
+		return fmt.Sprintf("@%s Could not find player %s", user, player)
+	}
+}
+
+// sayLookupFailure Replies to a failed hiscore lookup. While the Hiscore API is
+// down, only one reply is sent per channel within the OutageNotices window
+func (bot *OziachBot) sayLookupFailure(channel, user, player string, err error) {
+	log.Printf("Hiscore lookup of %s failed: %s", player, err)
+
+	if isHiscoreOutage(err) && !bot.OutageNotices.Allow(channel) {
+		return
+	}
+
+	bot.Say(channel, FormatLookupFailureOutput(user, player, err))
+}
+
+// lookupHiscores Looks up the player's hiscores, recording a snapshot of them if
+// OziachBot keeps snapshots
+func (bot *OziachBot) lookupHiscores(player string) (Hiscores, GameMode, error) {
+	playerHiscores, mode, err := bot.HiscoreAPI.LookupHiscores(player)
+
+	if err == nil {
+		bot.recordSnapshot(player, mode, playerHiscores)
+	}
+
+	return playerHiscores, mode, err
+}
+
 // HandleSkillLookup parses user message and sends the formatted result of a skill lookup
 func (bot *OziachBot) HandleSkillLookup(channel, user, skillName, player string) error {
-	playerHiscores, mode, err := bot.HiscoreAPI.LookupHiscores(player)
+	// A channel without a record gets the default settings and no aliases
+	obChannel, _ := bot.ChannelDB.GetChannel(channel)
+	matched, err := bot.matchSkill(channel, user, skillName, obChannel.Aliases)
+
+	// If the name doesn't map to a skill, there's nothing to look up
 	if err != nil {
-		bot.Say(channel, fmt.Sprintf("@%s Could not find player %s", user, player))
 		return err
 	}
 
-	name, skill, err := playerHiscores.GetSkillHiscoreFromName(skillName)
-
-	// If the name doesn't map to a skill, the bot silently fails to retrieve it
+	playerHiscores, mode, err := bot.lookupHiscores(player)
 	if err != nil {
+		bot.sayLookupFailure(channel, user, player, err)
 		return err
 	}
 
-	bot.Say(channel, FormatSkillLookupOutput(user, player, name, mode, skill))
+	name, skill := skillNames[matched], playerHiscores.skills[matched]
+
+	bot.Say(channel, FormatSkillLookupOutput(user, player, name, mode, skill, obChannel.Settings))
 
 	return nil
 }
 
 // FormatSkillLookupOutput Formats the information returned by OziachBot upon a successful
-// skill lookup
-func FormatSkillLookupOutput(user, player, skillName string, mode GameMode, skill SkillHiscore) string {
-	return fmt.Sprintf(
+// skill lookup, with the optional details enabled in settings. Overall has no level
+// of its own, so it never gets any
+func FormatSkillLookupOutput(user, player, skillName string, mode GameMode, skill SkillHiscore, settings ChannelSettings) string {
+	output := fmt.Sprintf(
 		"@%s - %s | %s level: %s | Rank (%s): %s | Exp: %s",
 		user,
 		player,
@@ -46,4 +96,692 @@ func FormatSkillLookupOutput(user, player, skillName string, mode GameMode, skil
 		humanize.Comma(int64(skill.Rank)),
 		humanize.Comma(int64(skill.Exp)),
 	)
+
+	if skillName == skillNames[SkillOverall] {
+		return output
+	}
+
+	level := skill.Level
+	maxLevel := xp.MaxRealLevel
+
+	if settings.VirtualLevels {
+		level = xp.VirtualLevel(skill.Exp)
+		maxLevel = xp.MaxLevel
+
+		if level > skill.Level {
+			output += fmt.Sprintf(" | Virtual level: %d", level)
+		}
+	}
+
+	if settings.ExpToNextLevel && level < maxLevel {
+		output += fmt.Sprintf(
+			" | Exp to %d: %s",
+			level+1,
+			humanize.Comma(int64(xp.ForLevel(level+1)-skill.Exp)),
+		)
+	}
+
+	if settings.PercentToMax {
+		if skill.Exp < xp.ForLevel(xp.MaxRealLevel) {
+			output += fmt.Sprintf(" | %s to 99", formatPercent(skill.Exp, xp.ForLevel(xp.MaxRealLevel)))
+		} else if skill.Exp < xp.MaxExp {
+			output += fmt.Sprintf(" | %s to 200M exp", formatPercent(skill.Exp, xp.MaxExp))
+		}
+	}
+
+	return output
+}
+
+// formatPercent Formats part as a percentage of total, rounded down to one decimal
+// place so that it never reads 100% early
+func formatPercent(part, total int) string {
+	return fmt.Sprintf("%.1f%%", float64(part*1000/total)/10)
+}
+
+// ParseExp Parses an amount of exp as written in chat, which may have thousands
+// separators or a k or m suffix (e.g. "13,034,431" or "13.03m")
+func ParseExp(text string) (int, error) {
+	text = strings.ToLower(strings.Replace(strings.TrimSpace(text), ",", "", -1))
+	multiplier := 1.0
+
+	switch {
+	case strings.HasSuffix(text, "k"):
+		multiplier = 1e3
+	case strings.HasSuffix(text, "m"):
+		multiplier = 1e6
+	}
+
+	if multiplier == 1 {
+		return strconv.Atoi(text)
+	}
+
+	value, err := strconv.ParseFloat(text[:len(text)-1], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(value * multiplier), nil
+}
+
+// HandleExpCalculation Sends the exp needed to reach a level. Returns
+// IncorrectFormatError without replying for levels outside of 1-126
+func (bot *OziachBot) HandleExpCalculation(channel, user string, level int) error {
+	if level < xp.MinLevel || level > xp.MaxLevel {
+		return &IncorrectFormatError{}
+	}
+
+	bot.Say(channel, FormatExpCalculationOutput(user, level))
+	return nil
+}
+
+// FormatExpCalculationOutput Formats the reply to an exp calculation
+func FormatExpCalculationOutput(user string, level int) string {
+	return fmt.Sprintf(
+		"@%s Level %d requires %s exp",
+		user,
+		level,
+		humanize.Comma(int64(xp.ForLevel(level))),
+	)
+}
+
+// HandleLevelCalculation Sends the level reached with an amount of exp. Returns
+// IncorrectFormatError without replying for amounts outside of 0-200M
+func (bot *OziachBot) HandleLevelCalculation(channel, user string, exp int) error {
+	if exp < 0 || exp > xp.MaxExp {
+		return &IncorrectFormatError{}
+	}
+
+	bot.Say(channel, FormatLevelCalculationOutput(user, exp))
+	return nil
+}
+
+// FormatLevelCalculationOutput Formats the reply to a level calculation, counting
+// virtual levels past 99
+func FormatLevelCalculationOutput(user string, exp int) string {
+	level := xp.VirtualLevel(exp)
+	output := fmt.Sprintf("@%s %s exp is level %d", user, humanize.Comma(int64(exp)), level)
+
+	if level < xp.MaxLevel {
+		output += fmt.Sprintf(
+			" | Exp to %d: %s",
+			level+1,
+			humanize.Comma(int64(xp.ToNextLevel(exp))),
+		)
+	}
+
+	return output
+}
+
+// HandleKillCountLookup parses user message and sends the formatted result of a boss
+// kill count lookup
+func (bot *OziachBot) HandleKillCountLookup(channel, user, bossName, player string) error {
+	playerHiscores, mode, err := bot.lookupHiscores(player)
+	if err != nil {
+		bot.sayLookupFailure(channel, user, player, err)
+		return err
+	}
+
+	name, boss, err := playerHiscores.GetBossHiscoreFromName(bossName)
+
+	switch err.(type) {
+	case nil:
+		bot.Say(channel, FormatKillCountLookupOutput(user, player, name, mode, boss))
+	case *UnrankedError:
+		bot.Say(channel, fmt.Sprintf("@%s %s is not ranked in %s kill count", user, player, name))
+	}
+
+	// If the name doesn't map to a boss, the bot silently fails to retrieve it
+	return err
+}
+
+// HandleClueLookup Sends the formatted result of a clue scroll lookup for the tier
+func (bot *OziachBot) HandleClueLookup(channel, user, tier, player string) error {
+	playerHiscores, mode, err := bot.lookupHiscores(player)
+	if err != nil {
+		bot.sayLookupFailure(channel, user, player, err)
+		return err
+	}
+
+	name, clue, err := playerHiscores.GetClueHiscoreFromName(tier)
+	activity := fmt.Sprintf("Clue scrolls (%s)", strings.ToLower(name))
+	if name == clueNames[ClueOverallClues] {
+		activity = "Clue scrolls (all)"
+	}
+
+	bot.sayMinigameLookup(channel, user, player, activity, mode, clue, err)
+
+	// If the name doesn't map to a tier, the bot silently fails to retrieve it
+	return err
+}
+
+// HandleLMSLookup Sends the formatted result of a Last Man Standing lookup
+func (bot *OziachBot) HandleLMSLookup(channel, user, player string) error {
+	playerHiscores, mode, err := bot.lookupHiscores(player)
+	if err != nil {
+		bot.sayLookupFailure(channel, user, player, err)
+		return err
+	}
+
+	lms, err := playerHiscores.LastManStanding()
+	bot.sayMinigameLookup(channel, user, player, "Last Man Standing", mode, lms, err)
+
+	return err
+}
+
+// HandleBountyHunterLookup Sends the formatted result of a Bounty Hunter lookup.
+// Both Hunter and Rogue scores are sent if kind is empty
+func (bot *OziachBot) HandleBountyHunterLookup(channel, user, kind, player string) error {
+	playerHiscores, mode, err := bot.lookupHiscores(player)
+	if err != nil {
+		bot.sayLookupFailure(channel, user, player, err)
+		return err
+	}
+
+	if kind != "" {
+		name, bountyHunter, err := playerHiscores.GetBountyHunterHiscoreFromName(kind)
+		activity := fmt.Sprintf("Bounty Hunter - %s", name)
+		bot.sayMinigameLookup(channel, user, player, activity, mode, bountyHunter, err)
+
+		// If the name doesn't map to a score, the bot silently fails to retrieve it
+		return err
+	}
+
+	scores := make([]string, len(bountyHunterNames))
+	ranked := false
+
+	for i, name := range bountyHunterNames {
+		_, bountyHunter, err := playerHiscores.GetBountyHunterHiscoreFromName(name)
+
+		if err != nil {
+			scores[i] = fmt.Sprintf("%s: unranked", name)
+			continue
+		}
+
+		ranked = true
+		scores[i] = fmt.Sprintf(
+			"%s: %s (Rank %s)",
+			name,
+			humanize.Comma(int64(bountyHunter.Score)),
+			humanize.Comma(int64(bountyHunter.Rank)),
+		)
+	}
+
+	if !ranked {
+		bot.Say(channel, FormatUnrankedOutput(user, player, "Bounty Hunter"))
+		return &UnrankedError{}
+	}
+
+	bot.Say(channel, fmt.Sprintf(
+		"@%s - %s | Bounty Hunter (%s) | %s",
+		user,
+		player,
+		mode.Name,
+		strings.Join(scores, " | "),
+	))
+
+	return nil
+}
+
+// sayMinigameLookup Replies to a minigame lookup based on the error it returned.
+// Names that didn't map to a hiscore get no reply
+func (bot *OziachBot) sayMinigameLookup(channel, user, player, activity string, mode GameMode, minigame MinigameHiscore, err error) {
+	switch err.(type) {
+	case nil:
+		bot.Say(channel, FormatMinigameLookupOutput(user, player, activity, mode, minigame))
+	case *UnrankedError:
+		bot.Say(channel, FormatUnrankedOutput(user, player, activity))
+	}
+}
+
+// FormatMinigameLookupOutput Formats the information returned by OziachBot upon a
+// successful lookup of any hiscore that isn't a skill
+func FormatMinigameLookupOutput(user, player, activity string, mode GameMode, minigame MinigameHiscore) string {
+	return fmt.Sprintf(
+		"@%s - %s | %s: %s | Rank (%s): %s",
+		user,
+		player,
+		activity,
+		humanize.Comma(int64(minigame.Score)),
+		mode.Name,
+		humanize.Comma(int64(minigame.Rank)),
+	)
+}
+
+// FormatUnrankedOutput Formats the reply sent by OziachBot when a player isn't
+// ranked in the hiscore they were looked up for
+func FormatUnrankedOutput(user, player, activity string) string {
+	return fmt.Sprintf("@%s %s is not ranked in %s", user, player, activity)
+}
+
+// FormatKillCountLookupOutput Formats the information returned by OziachBot upon a
+// successful boss kill count lookup
+func FormatKillCountLookupOutput(user, player, bossName string, mode GameMode, boss MinigameHiscore) string {
+	return fmt.Sprintf(
+		"@%s - %s | %s kill count: %s | Rank (%s): %s",
+		user,
+		player,
+		bossName,
+		humanize.Comma(int64(boss.Score)),
+		mode.Name,
+		humanize.Comma(int64(boss.Rank)),
+	)
+}
+
+// HandleCombatLookup Sends the formatted result of a combat level lookup
+func (bot *OziachBot) HandleCombatLookup(channel, user, player string) error {
+	playerHiscores, _, err := bot.lookupHiscores(player)
+	if err != nil {
+		bot.sayLookupFailure(channel, user, player, err)
+		return err
+	}
+
+	bot.Say(channel, FormatCombatLookupOutput(
+		user,
+		player,
+		playerHiscores.CombatLevel(),
+		playerHiscores.NextCombatLevel(),
+	))
+
+	return nil
+}
+
+// FormatCombatLookupOutput Formats the information returned by OziachBot upon a
+// successful combat level lookup
+func FormatCombatLookupOutput(user, player string, combat CombatLevel, next []SkillRequirement) string {
+	output := fmt.Sprintf(
+		"@%s - %s | Combat level: %d (%s)",
+		user,
+		player,
+		combat.Level,
+		combat.Style,
+	)
+
+	if len(next) == 0 {
+		return output
+	}
+
+	requirements := make([]string, len(next))
+	for i, requirement := range next {
+		requirements[i] = fmt.Sprintf("+%d %s", requirement.Levels, skillNames[requirement.Skill])
+	}
+
+	return fmt.Sprintf("%s | Next level: %s", output, strings.Join(requirements, ", "))
+}
+
+// skillAbbreviations Short skill names concurrent to skillNames, used when the full
+// names don't fit in a message
+var skillAbbreviations = []string{
+	"Total",
+	"Atk",
+	"Def",
+	"Str",
+	"HP",
+	"Rng",
+	"Pray",
+	"Mage",
+	"Cook",
+	"WC",
+	"Fletch",
+	"Fish",
+	"FM",
+	"Craft",
+	"Smith",
+	"Mine",
+	"Herb",
+	"Agil",
+	"Thiev",
+	"Slay",
+	"Farm",
+	"RC",
+	"Hunt",
+	"Con",
+}
+
+// HandleStatsLookup Sends every skill level of a player, split across as many
+// messages as needed
+func (bot *OziachBot) HandleStatsLookup(channel, user, player string) error {
+	playerHiscores, mode, err := bot.lookupHiscores(player)
+	if err != nil {
+		bot.sayLookupFailure(channel, user, player, err)
+		return err
+	}
+
+	for _, message := range FormatStatsLookupOutput(user, player, mode, playerHiscores) {
+		bot.Say(channel, message)
+	}
+
+	return nil
+}
+
+// FormatStatsLookupOutput Formats every skill level of a player in skillNames order,
+// along with total and combat level. Full skill names are used if they fit in a
+// single message, otherwise they're abbreviated, and split across messages only if
+// that still doesn't fit
+func FormatStatsLookupOutput(user, player string, mode GameMode, hiscores Hiscores) []string {
+	return formatStatsLookupOutput(user, player, mode, hiscores, maxSayLength)
+}
+
+func formatStatsLookupOutput(user, player string, mode GameMode, hiscores Hiscores, limit int) []string {
+	header := fmt.Sprintf(
+		"@%s - %s (%s) | Total: %s | Combat: %d",
+		user,
+		player,
+		mode.Name,
+		humanize.Comma(int64(hiscores.skills[SkillOverall].Level)),
+		hiscores.CombatLevel().Level,
+	)
+
+	full := make([]string, 0, len(skillNames)-1)
+	abbreviated := make([]string, 0, len(skillNames)-1)
+	for skill := SkillAttack; int(skill) < len(skillNames); skill++ {
+		level := hiscores.skills[skill].Level
+		full = append(full, fmt.Sprintf("%s: %d", skillNames[skill], level))
+		abbreviated = append(abbreviated, fmt.Sprintf("%s %d", skillAbbreviations[skill], level))
+	}
+
+	if messages := splitMessage(header, full, " | ", limit); len(messages) == 1 {
+		return messages
+	}
+
+	return splitMessage(header, abbreviated, " | ", limit)
+}
+
+// splitMessage Joins header and parts with sep into as few messages as possible
+// without going over limit. Parts are never split, and the header only starts the
+// first message
+func splitMessage(header string, parts []string, sep string, limit int) []string {
+	messages := []string{}
+	current := header
+
+	for _, part := range parts {
+		switch {
+		case current == "":
+			current = part
+		case len(current)+len(sep)+len(part) <= limit:
+			current += sep + part
+		default:
+			messages = append(messages, current)
+			current = part
+		}
+	}
+
+	if current != "" {
+		messages = append(messages, current)
+	}
+
+	return messages
+}
+
+// compareAllSkills Names that compare every skill instead of a single one
+var compareAllSkills = map[string]struct{}{
+	"all":    struct{}{},
+	"stats":  struct{}{},
+	"skills": struct{}{},
+}
+
+// lookupPlayers Looks up the hiscores of every player concurrently. The results
+// and errors are in the same order as players
+func (bot *OziachBot) lookupPlayers(players ...string) ([]Hiscores, []error) {
+	results := make([]Hiscores, len(players))
+	errs := make([]error, len(players))
+
+	var wg sync.WaitGroup
+	wg.Add(len(players))
+
+	for i, player := range players {
+		go func(i int, player string) {
+			defer wg.Done()
+			results[i], _, errs[i] = bot.lookupHiscores(player)
+		}(i, player)
+	}
+
+	wg.Wait()
+	return results, errs
+}
+
+// HandleCompareLookup Sends a side by side comparison of two players in a skill,
+// or in every skill if skillName is one of compareAllSkills
+func (bot *OziachBot) HandleCompareLookup(channel, user, skillName, player1, player2 string) error {
+	_, compareAll := compareAllSkills[strings.ToLower(skillName)]
+
+	var matched Skill
+	if !compareAll {
+		obChannel, _ := bot.ChannelDB.GetChannel(channel)
+
+		var err error
+		matched, err = bot.matchSkill(channel, user, skillName, obChannel.Aliases)
+
+		// If the name doesn't map to a skill, there's nothing to compare
+		if err != nil {
+			return err
+		}
+	}
+
+	playerHiscores, errs := bot.lookupPlayers(player1, player2)
+
+	for i, player := range []string{player1, player2} {
+		if errs[i] != nil {
+			bot.sayLookupFailure(channel, user, player, errs[i])
+			return errs[i]
+		}
+	}
+
+	if compareAll {
+		for _, message := range FormatStatsComparisonOutput(user, player1, playerHiscores[0], player2, playerHiscores[1]) {
+			bot.Say(channel, message)
+		}
+
+		return nil
+	}
+
+	bot.Say(channel, FormatSkillComparisonOutput(
+		user,
+		skillNames[matched],
+		player1,
+		playerHiscores[0].skills[matched],
+		player2,
+		playerHiscores[1].skills[matched],
+	))
+
+	return nil
+}
+
+// FormatSkillComparisonOutput Formats the comparison of two players in a single skill
+func FormatSkillComparisonOutput(user, skillName, player1 string, skill1 SkillHiscore, player2 string, skill2 SkillHiscore) string {
+	output := fmt.Sprintf(
+		"@%s %s | %s | %s",
+		user,
+		skillName,
+		formatComparedSkill(player1, skill1),
+		formatComparedSkill(player2, skill2),
+	)
+
+	leader, leading, trailing := player1, skill1, skill2
+	if skill2.Exp > skill1.Exp {
+		leader, leading, trailing = player2, skill2, skill1
+	}
+
+	if leading.Exp == trailing.Exp {
+		return output + " | Tied"
+	}
+
+	// Players in the same level only differ in exp, and unranked players have no rank
+	// to compare
+	leads := []string{}
+	if levels := leading.Level - trailing.Level; levels > 0 {
+		leads = append(leads, pluralize(levels, "level", "levels"))
+	}
+	leads = append(leads, humanize.Comma(int64(leading.Exp-trailing.Exp))+" exp")
+	if ranks := trailing.Rank - leading.Rank; leading.Rank > 0 && trailing.Rank > 0 && ranks > 0 {
+		leads = append(leads, pluralize(ranks, "rank", "ranks"))
+	}
+
+	return fmt.Sprintf("%s | %s leads by %s", output, leader, joinAnd(leads))
+}
+
+// pluralize Formats a count with the singular or plural form of what it counts
+func pluralize(count int, singular, plural string) string {
+	if count == 1 {
+		return "1 " + singular
+	}
+
+	return humanize.Comma(int64(count)) + " " + plural
+}
+
+// joinAnd Joins words into a list like "A, B and C"
+func joinAnd(words []string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+// formatComparedSkill Formats one side of a skill comparison
+func formatComparedSkill(player string, skill SkillHiscore) string {
+	rank := "Unranked"
+	if skill.Rank > 0 {
+		rank = "Rank " + humanize.Comma(int64(skill.Rank))
+	}
+
+	return fmt.Sprintf(
+		"%s: Lvl %s, %s exp, %s",
+		player,
+		humanize.Comma(int64(skill.Level)),
+		humanize.Comma(int64(skill.Exp)),
+		rank,
+	)
+}
+
+// FormatStatsComparisonOutput Formats the comparison of two players in every skill,
+// in skillNames order, split across messages like FormatStatsLookupOutput
+func FormatStatsComparisonOutput(user, player1 string, hiscores1 Hiscores, player2 string, hiscores2 Hiscores) []string {
+	ahead1, ahead2 := 0, 0
+	parts := make([]string, 0, len(skillNames)-1)
+
+	for skill := SkillAttack; int(skill) < len(skillNames); skill++ {
+		skill1, skill2 := hiscores1.skills[skill], hiscores2.skills[skill]
+
+		if skill1.Exp > skill2.Exp {
+			ahead1++
+		} else if skill2.Exp > skill1.Exp {
+			ahead2++
+		}
+
+		parts = append(parts, fmt.Sprintf("%s %d-%d", skillAbbreviations[skill], skill1.Level, skill2.Level))
+	}
+
+	header := fmt.Sprintf(
+		"@%s %s vs %s | Skills ahead: %d-%d | Total: %s-%s | Combat: %d-%d",
+		user,
+		player1,
+		player2,
+		ahead1,
+		ahead2,
+		humanize.Comma(int64(hiscores1.skills[SkillOverall].Level)),
+		humanize.Comma(int64(hiscores2.skills[SkillOverall].Level)),
+		hiscores1.CombatLevel().Level,
+		hiscores2.CombatLevel().Level,
+	)
+
+	return splitMessage(header, parts, " | ", maxSayLength)
+}
+
+// gainedPeriod Period a !gained command covers
+type gainedPeriod struct {
+	// duration Length of the period, 0 for the current stream
+	duration time.Duration
+	label    string
+}
+
+// maxListedGains Number of skills listed in a !gained reply
+const maxListedGains = 5
+
+// gainedPeriods Period name mapping to gainedPeriod
+var gainedPeriods = map[string]gainedPeriod{
+	"day":    {24 * time.Hour, "in the past day"},
+	"today":  {24 * time.Hour, "in the past day"},
+	"week":   {7 * 24 * time.Hour, "in the past week"},
+	"month":  {30 * 24 * time.Hour, "in the past month"},
+	"stream": {0, "this stream"},
+}
+
+// HandleGainedLookup Sends the exp a player gained over a period, based on the
+// oldest snapshot of them within it. The stream period starts when OziachBot
+// joined the channel
+func (bot *OziachBot) HandleGainedLookup(channel, user, periodName, player string) error {
+	period, ok := gainedPeriods[strings.ToLower(periodName)]
+
+	// Without snapshots or a known period, the bot silently fails
+	if !ok || bot.Snapshots == nil {
+		return &IncorrectFormatError{}
+	}
+
+	now := time.Now()
+	since := now.Add(-period.duration)
+	if period.duration == 0 {
+		if since, ok = bot.JoinTimes.Get(channel); !ok {
+			return &IncorrectFormatError{}
+		}
+	}
+
+	snapshots, err := bot.Snapshots.GetSnapshots(player, since)
+	if err != nil {
+		log.Printf("Could not get snapshots of %s: %s", player, err)
+		return err
+	}
+
+	playerHiscores, mode, err := bot.lookupHiscores(player)
+	if err != nil {
+		bot.sayLookupFailure(channel, user, player, err)
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		bot.Say(channel, fmt.Sprintf("@%s %s isn't tracked yet, gains will be counted from now on", user, player))
+		return nil
+	}
+
+	overall, gains := CompareSnapshots(snapshots[0], NewSnapshot(player, mode, playerHiscores, now))
+	bot.Say(channel, FormatGainedLookupOutput(user, player, period.label, overall, gains))
+
+	return nil
+}
+
+// FormatGainedLookupOutput Formats the overall gain of a player over a period,
+// followed by the skills with the most exp gained
+func FormatGainedLookupOutput(user, player, period string, overall SkillGain, gains []SkillGain) string {
+	output := fmt.Sprintf(
+		"@%s - %s | Gained %s: %s exp, %s levels",
+		user,
+		player,
+		period,
+		humanize.Comma(int64(overall.Exp)),
+		humanize.Comma(int64(overall.Levels)),
+	)
+
+	return output + formatSkillGains(gains)
+}
+
+// formatSkillGains Formats the skills with the most exp gained
+func formatSkillGains(gains []SkillGain) string {
+	if len(gains) == 0 {
+		return " | No exp gained"
+	}
+
+	if len(gains) > maxListedGains {
+		gains = gains[:maxListedGains]
+	}
+
+	output := ""
+	for _, gain := range gains {
+		output += fmt.Sprintf(
+			" | %s +%s exp (+%d)",
+			skillNames[gain.Skill],
+			humanize.Comma(int64(gain.Exp)),
+			gain.Levels,
+		)
+	}
+
+	return output
 }
@@ -0,0 +1,250 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatSkillLookupOutput(t *testing.T) {
+	skill := SkillHiscore{Rank: 1234, Level: 99, Exp: 20000000}
+
+	testCases := []struct {
+		Name      string
+		SkillName string
+		Skill     SkillHiscore
+		Settings  ChannelSettings
+		Expected  string
+	}{
+		{
+			Name:      "Default",
+			SkillName: "Ranged",
+			Skill:     skill,
+			Expected:  "@user - player | Ranged level: 99 | Rank (Normal): 1,234 | Exp: 20,000,000",
+		},
+		{
+			Name:      "AllEnabled",
+			SkillName: "Ranged",
+			Skill:     skill,
+			Settings:  ChannelSettings{VirtualLevels: true, ExpToNextLevel: true, PercentToMax: true},
+			Expected: "@user - player | Ranged level: 99 | Rank (Normal): 1,234 | Exp: 20,000,000" +
+				" | Virtual level: 103 | Exp to 104: 1,385,073 | 10.0% to 200M exp",
+		},
+		{
+			Name:      "NextRealLevel",
+			SkillName: "Ranged",
+			Skill:     SkillHiscore{Rank: 342695, Level: 90, Exp: 5866885},
+			Settings:  ChannelSettings{ExpToNextLevel: true, PercentToMax: true},
+			Expected: "@user - player | Ranged level: 90 | Rank (Normal): 342,695 | Exp: 5,866,885" +
+				" | Exp to 91: 35,946 | 45.0% to 99",
+		},
+		{
+			Name:      "NoNextRealLevel",
+			SkillName: "Ranged",
+			Skill:     skill,
+			Settings:  ChannelSettings{ExpToNextLevel: true},
+			Expected:  "@user - player | Ranged level: 99 | Rank (Normal): 1,234 | Exp: 20,000,000",
+		},
+		{
+			Name:      "Overall",
+			SkillName: "Overall",
+			Skill:     SkillHiscore{Rank: 1140740, Level: 922, Exp: 26362111},
+			Settings:  ChannelSettings{VirtualLevels: true, ExpToNextLevel: true, PercentToMax: true},
+			Expected:  "@user - player | Overall level: 922 | Rank (Normal): 1,140,740 | Exp: 26,362,111",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			actual := FormatSkillLookupOutput("user", "player", tc.SkillName, GameModeNormal, tc.Skill, tc.Settings)
+
+			if actual != tc.Expected {
+				t.Errorf("Expected %s, got %s", tc.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseExp(t *testing.T) {
+	testCases := []struct {
+		Text     string
+		Expected int
+		Valid    bool
+	}{
+		{"13034431", 13034431, true},
+		{"13,034,431", 13034431, true},
+		{"200M", 200000000, true},
+		{"13.5m", 13500000, true},
+		{"750k", 750000, true},
+		{"ranged", 0, false},
+		{"m", 0, false},
+	}
+
+	for _, tc := range testCases {
+		actual, err := ParseExp(tc.Text)
+
+		if (err == nil) != tc.Valid {
+			t.Errorf("%s: expected valid to be %v, got error %v", tc.Text, tc.Valid, err)
+		} else if actual != tc.Expected {
+			t.Errorf("%s: expected %d, got %d", tc.Text, tc.Expected, actual)
+		}
+	}
+}
+
+func TestFormatStatsLookupOutput(t *testing.T) {
+	hiscores, err := NewMockHiscoreAPI().LookupHiscoresByGameMode(ironmanAccount, GameModeIronman)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("FullNames", func(t *testing.T) {
+		messages := FormatStatsLookupOutput("user", ironmanAccount, GameModeIronman, hiscores)
+
+		if len(messages) != 1 {
+			t.Fatalf("Expected 1 message, got %d", len(messages))
+		}
+
+		expectedStart := "@user - " + ironmanAccount + " (Ironman) | Total: 922 | Combat: 74 | Attack: 50 | Defense: 1 | "
+		if !strings.HasPrefix(messages[0], expectedStart) {
+			t.Errorf("Expected message to start with %s, got %s", expectedStart, messages[0])
+		}
+
+		if !strings.HasSuffix(messages[0], " | Construction: 65") {
+			t.Errorf("Expected message to end with Construction, got %s", messages[0])
+		}
+	})
+
+	t.Run("Abbreviated", func(t *testing.T) {
+		messages := formatStatsLookupOutput("user", ironmanAccount, GameModeIronman, hiscores, 300)
+
+		if len(messages) != 1 {
+			t.Fatalf("Expected 1 message, got %d", len(messages))
+		}
+
+		if !strings.Contains(messages[0], " | Atk 50 | Def 1 | ") || !strings.HasSuffix(messages[0], " | Con 65") {
+			t.Errorf("Expected abbreviated skill names, got %s", messages[0])
+		}
+	})
+
+	t.Run("Split", func(t *testing.T) {
+		limit := 100
+		messages := formatStatsLookupOutput("user", ironmanAccount, GameModeIronman, hiscores, limit)
+
+		if len(messages) < 2 {
+			t.Fatalf("Expected several messages, got %d", len(messages))
+		}
+
+		for _, message := range messages {
+			if len(message) > limit {
+				t.Errorf("Message of length %d exceeds limit %d: %s", len(message), limit, message)
+			}
+		}
+
+		if joined := strings.Join(messages, " | "); !strings.HasSuffix(joined, " | Con 65") {
+			t.Errorf("Expected every skill across messages, got %s", joined)
+		}
+	})
+}
+
+func TestSplitMessage(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Header   string
+		Parts    []string
+		Limit    int
+		Expected []string
+	}{
+		{"Fits", "head", []string{"a", "b"}, 20, []string{"head | a | b"}},
+		{"Split", "head", []string{"aaaa", "bbbb", "cccc"}, 11, []string{"head | aaaa", "bbbb | cccc"}},
+		{"NoHeader", "", []string{"a", "b"}, 20, []string{"a | b"}},
+	}
+
+	for _, tc := range testCases {
+		actual := splitMessage(tc.Header, tc.Parts, " | ", tc.Limit)
+
+		if strings.Join(actual, "\n") != strings.Join(tc.Expected, "\n") {
+			t.Errorf("%s: expected %q, got %q", tc.Name, tc.Expected, actual)
+		}
+	}
+}
+
+func TestFormatSkillComparisonOutput(t *testing.T) {
+	t.Run("Lead", func(t *testing.T) {
+		expected := "@user Ranged | p1: Lvl 86, 3,732,405 exp, Rank 505,273 | " +
+			"p2: Lvl 90, 5,866,885 exp, Unranked | p2 leads by 4 levels and 2,134,480 exp"
+		actual := FormatSkillComparisonOutput(
+			"user",
+			"Ranged",
+			"p1",
+			SkillHiscore{Rank: 505273, Level: 86, Exp: 3732405},
+			"p2",
+			SkillHiscore{Rank: -1, Level: 90, Exp: 5866885},
+		)
+
+		if actual != expected {
+			t.Errorf("Expected %s, got %s", expected, actual)
+		}
+	})
+
+	t.Run("RankedLead", func(t *testing.T) {
+		expected := "@user Ranged | p1: Lvl 90, 5,866,885 exp, Rank 342,695 | " +
+			"p2: Lvl 89, 5,000,000 exp, Rank 400,000 | p1 leads by 1 level, 866,885 exp and 57,305 ranks"
+		actual := FormatSkillComparisonOutput(
+			"user",
+			"Ranged",
+			"p1",
+			SkillHiscore{Rank: 342695, Level: 90, Exp: 5866885},
+			"p2",
+			SkillHiscore{Rank: 400000, Level: 89, Exp: 5000000},
+		)
+
+		if actual != expected {
+			t.Errorf("Expected %s, got %s", expected, actual)
+		}
+	})
+
+	t.Run("SameLevel", func(t *testing.T) {
+		expected := "@user Ranged | p1: Lvl 99, 13,034,431 exp, Rank 2 | " +
+			"p2: Lvl 99, 13,034,500 exp, Rank 1 | p2 leads by 69 exp and 1 rank"
+		actual := FormatSkillComparisonOutput(
+			"user",
+			"Ranged",
+			"p1",
+			SkillHiscore{Rank: 2, Level: 99, Exp: 13034431},
+			"p2",
+			SkillHiscore{Rank: 1, Level: 99, Exp: 13034500},
+		)
+
+		if actual != expected {
+			t.Errorf("Expected %s, got %s", expected, actual)
+		}
+	})
+
+	t.Run("Tied", func(t *testing.T) {
+		skill := SkillHiscore{Rank: 1, Level: 99, Exp: 200000000}
+		actual := FormatSkillComparisonOutput("user", "Ranged", "p1", skill, "p2", skill)
+
+		if !strings.HasSuffix(actual, " | Tied") {
+			t.Errorf("Expected a tie, got %s", actual)
+		}
+	})
+}
+
+func TestFormatStatsComparisonOutput(t *testing.T) {
+	hiscores1, _ := NewMockHiscoreAPI().LookupHiscoresByGameMode(normalAccount, GameModeNormal)
+	hiscores2 := newHiscores()
+	for skill := range hiscores2.skills {
+		hiscores2.skills[skill] = SkillHiscore{Rank: 1, Level: 40, Exp: 40000}
+	}
+
+	messages := FormatStatsComparisonOutput("user", "p1", hiscores1, "p2", hiscores2)
+
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+
+	expectedStart := "@user p1 vs p2 | Skills ahead: 10-13 | Total: 922-40 | Combat: 74-51 | Atk 50-40 | Def 1-40 | "
+	if !strings.HasPrefix(messages[0], expectedStart) {
+		t.Errorf("Expected message to start with %s, got %s", expectedStart, messages[0])
+	}
+}
@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cooldown Time a command can't be run again for after it's run, in seconds, at
+// each scope. A scope with no time is never on cooldown
+type Cooldown struct {
+	// Channel Time before anyone in the channel can run the command again
+	Channel int `json:"channel,omitempty"`
+	// User Time before the same user can run the command again
+	User int `json:"user,omitempty"`
+	// Argument Time before the command can be run with the same arguments again
+	Argument int `json:"argument,omitempty"`
+}
+
+// InvalidCooldownError Returned when a command is given a negative Cooldown
+type InvalidCooldownError struct {
+	Path     string
+	Cooldown Cooldown
+}
+
+func (e *InvalidCooldownError) Error() string {
+	return fmt.Sprintf("Invalid cooldown %+v for command %s, times can't be negative", e.Cooldown, e.Path)
+}
+
+// resolveCooldowns Maps the cooldowns of commands to their paths by name instead
+// of any alias, dropping cooldowns with no time
+func (bot *OziachBot) resolveCooldowns(cooldowns map[string]Cooldown) (map[string]Cooldown, error) {
+	resolved := make(map[string]Cooldown, len(cooldowns))
+
+	for path, cooldown := range cooldowns {
+		_, resolvedPath, ok := bot.commandRegistry().Resolve(strings.TrimPrefix(path, CommandPrefix))
+		if !ok {
+			return nil, &UnknownCommandError{path}
+		}
+
+		if cooldown.Channel < 0 || cooldown.User < 0 || cooldown.Argument < 0 {
+			return nil, &InvalidCooldownError{path, cooldown}
+		}
+
+		if cooldown != (Cooldown{}) {
+			resolved[resolvedPath] = cooldown
+		}
+	}
+
+	return resolved, nil
+}
+
+// knownCooldowns Returns a copy of the channel's cooldowns that can be changed and
+// passed back to ChangeSettings. Cooldowns of commands that are no longer
+// registered are dropped, so they don't fail every later change of the settings
+func (bot *OziachBot) knownCooldowns(channel Channel) map[string]Cooldown {
+	cooldowns := make(map[string]Cooldown, len(channel.Settings.Cooldowns))
+
+	for path, cooldown := range channel.Settings.Cooldowns {
+		if _, _, ok := bot.commandRegistry().Resolve(path); !ok {
+			log.Printf("Dropping cooldown of unknown command %s in channel %s", path, channel.Name)
+			continue
+		}
+
+		cooldowns[path] = cooldown
+	}
+
+	return cooldowns
+}
+
+// cooldownSweepSize Number of keys CommandCooldowns holds before it drops keys
+// that are off cooldown
+const cooldownSweepSize = 1024
+
+// CommandCooldowns Tracks which commands are on cooldown, in each channel
+type CommandCooldowns struct {
+	mutex   sync.Mutex
+	expires map[string]time.Time
+}
+
+// NewCommandCooldowns Returns CommandCooldowns with nothing on cooldown
+func NewCommandCooldowns() *CommandCooldowns {
+	return &CommandCooldowns{expires: map[string]time.Time{}}
+}
+
+// Allow Returns true and starts every cooldown of the invocation if none of them
+// are running, false otherwise. Moderators and the broadcaster are never on
+// cooldown, and don't start any. Nil CommandCooldowns allow everything
+func (cooldowns *CommandCooldowns) Allow(path string, ctx CommandContext, cooldown Cooldown) bool {
+	if cooldowns == nil || UserPermission(ctx.User) >= PermissionModerator {
+		return true
+	}
+
+	prefix := fmt.Sprintf("%s|%s|", ctx.Channel, path)
+	scopes := map[string]int{
+		prefix + "channel":                         cooldown.Channel,
+		prefix + "user|" + ctx.User.Username:       cooldown.User,
+		prefix + "argument|" + invocationArgs(ctx): cooldown.Argument,
+	}
+
+	cooldowns.mutex.Lock()
+	defer cooldowns.mutex.Unlock()
+
+	now := time.Now()
+	for key, seconds := range scopes {
+		if expires, ok := cooldowns.expires[key]; seconds > 0 && ok && now.Before(expires) {
+			return false
+		}
+	}
+
+	if len(cooldowns.expires) >= cooldownSweepSize {
+		for key, expires := range cooldowns.expires {
+			if !now.Before(expires) {
+				delete(cooldowns.expires, key)
+			}
+		}
+	}
+
+	for key, seconds := range scopes {
+		if seconds > 0 {
+			cooldowns.expires[key] = now.Add(time.Duration(seconds) * time.Second)
+		}
+	}
+
+	return true
+}
+
+// invocationArgs Returns the arguments of an invocation as one string, the same
+// regardless of how they were written
+func invocationArgs(ctx CommandContext) string {
+	args := append([]string{}, ctx.Args...)
+	args = append(args, ctx.Selector)
+
+	for _, player := range ctx.Players {
+		args = append(args, player.String())
+	}
+
+	return strings.ToLower(strings.Join(args, "|"))
+}
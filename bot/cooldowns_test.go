@@ -0,0 +1,267 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc"
+)
+
+func TestCommandCooldowns(t *testing.T) {
+	viewer := twitch.User{Username: "viewer"}
+	other := twitch.User{Username: "other"}
+	moderator := twitch.User{Username: "moderator", Badges: map[string]int{"moderator": 1}}
+
+	invocation := func(user twitch.User, players ...RSN) CommandContext {
+		return CommandContext{Channel: "channel", User: user, Args: []string{"ranged"}, Players: players}
+	}
+
+	t.Run("Channel", func(t *testing.T) {
+		cooldowns := NewCommandCooldowns()
+		cooldown := Cooldown{Channel: 30}
+
+		if !cooldowns.Allow("lvl", invocation(viewer, "Zezima"), cooldown) {
+			t.Fatal("First invocation was on cooldown")
+		}
+
+		if cooldowns.Allow("lvl", invocation(other, "Lynx Titan"), cooldown) {
+			t.Error("Invocation by another user was allowed during a channel cooldown")
+		}
+
+		if !cooldowns.Allow("stats", invocation(other, "Lynx Titan"), cooldown) {
+			t.Error("Another command was on cooldown")
+		}
+
+		otherChannel := invocation(other, "Lynx Titan")
+		otherChannel.Channel = "other channel"
+		if !cooldowns.Allow("lvl", otherChannel, cooldown) {
+			t.Error("Invocation in another channel was on cooldown")
+		}
+	})
+
+	t.Run("User", func(t *testing.T) {
+		cooldowns := NewCommandCooldowns()
+		cooldown := Cooldown{User: 30}
+
+		if !cooldowns.Allow("lvl", invocation(viewer, "Zezima"), cooldown) {
+			t.Fatal("First invocation was on cooldown")
+		}
+
+		if cooldowns.Allow("lvl", invocation(viewer, "Lynx Titan"), cooldown) {
+			t.Error("Invocation by the same user was allowed during a user cooldown")
+		}
+
+		if !cooldowns.Allow("lvl", invocation(other, "Zezima"), cooldown) {
+			t.Error("Invocation by another user was on cooldown")
+		}
+	})
+
+	t.Run("Argument", func(t *testing.T) {
+		cooldowns := NewCommandCooldowns()
+		cooldown := Cooldown{Argument: 30}
+
+		if !cooldowns.Allow("lvl", invocation(viewer, "Zezima"), cooldown) {
+			t.Fatal("First invocation was on cooldown")
+		}
+
+		if cooldowns.Allow("lvl", invocation(other, "zezima"), cooldown) {
+			t.Error("Invocation with the same arguments was allowed during an argument cooldown")
+		}
+
+		if !cooldowns.Allow("lvl", invocation(viewer, "Lynx Titan"), cooldown) {
+			t.Error("Invocation with other arguments was on cooldown")
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		cooldowns := NewCommandCooldowns()
+		cooldown := Cooldown{Channel: 30, User: 30}
+
+		cooldowns.Allow("lvl", invocation(viewer, "Zezima"), Cooldown{User: 30})
+
+		// The channel cooldown doesn't start for an invocation on user cooldown
+		if cooldowns.Allow("lvl", invocation(viewer, "Zezima"), cooldown) {
+			t.Fatal("Invocation was allowed during a user cooldown")
+		}
+
+		if !cooldowns.Allow("lvl", invocation(other, "Zezima"), cooldown) {
+			t.Error("Rejected invocation started a channel cooldown")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		cooldowns := NewCommandCooldowns()
+		cooldown := Cooldown{Channel: 30}
+		cooldowns.Allow("lvl", invocation(viewer, "Zezima"), cooldown)
+
+		for key := range cooldowns.expires {
+			cooldowns.expires[key] = time.Now().Add(-time.Second)
+		}
+
+		if !cooldowns.Allow("lvl", invocation(viewer, "Zezima"), cooldown) {
+			t.Error("Invocation after the cooldown was on cooldown")
+		}
+	})
+
+	t.Run("Moderator", func(t *testing.T) {
+		cooldowns := NewCommandCooldowns()
+		cooldown := Cooldown{Channel: 30, User: 30, Argument: 30}
+
+		for i := 0; i < 2; i++ {
+			if !cooldowns.Allow("lvl", invocation(moderator, "Zezima"), cooldown) {
+				t.Error("Moderator invocation was on cooldown")
+			}
+		}
+
+		if !cooldowns.Allow("lvl", invocation(viewer, "Zezima"), cooldown) {
+			t.Error("Moderator invocation started a cooldown")
+		}
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		var cooldowns *CommandCooldowns
+		cooldown := Cooldown{Channel: 30}
+
+		for i := 0; i < 2; i++ {
+			if !cooldowns.Allow("lvl", invocation(viewer, "Zezima"), cooldown) {
+				t.Error("Nil cooldowns put an invocation on cooldown")
+			}
+		}
+	})
+}
+
+func TestChangeSettingsCooldowns(t *testing.T) {
+	bot := NewMockBot()
+
+	t.Run("Resolved", func(t *testing.T) {
+		cooldowns, err := bot.resolveCooldowns(map[string]Cooldown{
+			"!level":        Cooldown{User: 10},
+			"session start": Cooldown{Channel: 60},
+			"stats":         Cooldown{},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]Cooldown{
+			"lvl":           Cooldown{User: 10},
+			"session start": Cooldown{Channel: 60},
+		}
+		if !reflect.DeepEqual(cooldowns, expected) {
+			t.Errorf("Expected cooldowns %v, found %v", expected, cooldowns)
+		}
+	})
+
+	t.Run("StaleStored", func(t *testing.T) {
+		channel := Channel{Name: "channel", Settings: ChannelSettings{Cooldowns: map[string]Cooldown{
+			"lvl":     Cooldown{User: 10},
+			"removed": Cooldown{Channel: 60},
+		}}}
+		bot := NewMockBot()
+		bot.ChannelDB = newStoredChannelDB(channel)
+
+		settings := channel.Settings
+		settings.Cooldowns = bot.knownCooldowns(channel)
+		settings.Cooldowns["stats"] = Cooldown{User: 5}
+
+		updated, err := bot.ChangeSettings("channel", settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]Cooldown{"lvl": Cooldown{User: 10}, "stats": Cooldown{User: 5}}
+		if !reflect.DeepEqual(updated.Settings.Cooldowns, expected) {
+			t.Errorf("Expected cooldowns %v, found %v", expected, updated.Settings.Cooldowns)
+		}
+	})
+
+	t.Run("UnknownCommand", func(t *testing.T) {
+		settings := ChannelSettings{Cooldowns: map[string]Cooldown{"sailing": Cooldown{User: 10}}}
+		_, err := bot.ChangeSettings(connectedChannel.Name, settings)
+
+		if _, ok := err.(*UnknownCommandError); !ok {
+			t.Errorf("Expected UnknownCommandError, found %v", err)
+		}
+	})
+
+	t.Run("Negative", func(t *testing.T) {
+		settings := ChannelSettings{Cooldowns: map[string]Cooldown{"lvl": Cooldown{User: -10}}}
+		_, err := bot.ChangeSettings(connectedChannel.Name, settings)
+
+		if _, ok := err.(*InvalidCooldownError); !ok {
+			t.Errorf("Expected InvalidCooldownError, found %v", err)
+		}
+	})
+}
+
+func TestDispatchCooldowns(t *testing.T) {
+	bot := NewMockBot()
+	bot.ChannelDB = newStoredChannelDB(Channel{
+		Name:     "channel",
+		Settings: ChannelSettings{Cooldowns: map[string]Cooldown{"lvl": Cooldown{User: 30}}},
+	})
+	bot.Cooldowns = NewCommandCooldowns()
+
+	viewer := twitch.User{Username: "viewer", DisplayName: "Viewer"}
+	messages := bot.TwitchClient.(*mockIRC).messageChan
+
+	bot.Dispatch("channel", viewer, "!lvl 13m")
+
+	select {
+	case <-messages:
+	case <-time.After(3 * time.Second):
+		t.Fatal("First invocation was not run")
+	}
+
+	bot.Dispatch("channel", viewer, "!lvl 13m")
+
+	select {
+	case message := <-messages:
+		t.Errorf("Invocation on cooldown was run, saying %s", message)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	bot.Dispatch("channel", viewer, "!xp 99")
+
+	select {
+	case <-messages:
+	case <-time.After(3 * time.Second):
+		t.Error("Command without a cooldown was not run")
+	}
+}
+
+func TestDispatchInvalidRSNCooldown(t *testing.T) {
+	bot := NewMockBot()
+	bot.ChannelDB = newStoredChannelDB(Channel{
+		Name:     "channel",
+		Settings: ChannelSettings{Cooldowns: map[string]Cooldown{"lvl": Cooldown{User: 30}}},
+	})
+	bot.Cooldowns = NewCommandCooldowns()
+
+	viewer := twitch.User{Username: "viewer", DisplayName: "Viewer"}
+	messages := bot.TwitchClient.(*mockIRC).messageChan
+
+	go bot.Dispatch("channel", viewer, "!lvl atk Not.Valid")
+
+	select {
+	case message := <-messages:
+		if expected := "/me " + FormatInvalidRSNOutput("Viewer", "Not.Valid"); message != expected {
+			t.Errorf("Said %s, but expected to say %s", message, expected)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Invalid RSN was not replied to")
+	}
+
+	// Replies to invalid RSNs start the cooldown like any other invocation
+	for _, text := range []string{"!lvl atk Not.Valid", "!lvl def Also.Not.Valid"} {
+		go bot.Dispatch("channel", viewer, text)
+
+		select {
+		case message := <-messages:
+			t.Errorf("Invocation on cooldown was replied to with %s", message)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
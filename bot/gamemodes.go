@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// GameModeNormal Normal game mode
+	GameModeNormal GameMode = GameMode{"Normal", ""}
+	// GameModeIronman Ironman game mode
+	GameModeIronman GameMode = GameMode{"Ironman", "_ironman"}
+	// GameModeHardcoreIronman Hardcore Ironman game mode
+	GameModeHardcoreIronman GameMode = GameMode{"Hardcore Ironman", "_hardcore_ironman"}
+	// GameModeUltimateIronman Ultimate Ironman game mode
+	GameModeUltimateIronman GameMode = GameMode{"Ultimate Ironman", "_ultimate"}
+	// GameModeGroupIronman Group Ironman game mode
+	GameModeGroupIronman GameMode = GameMode{"Group Ironman", "_group_ironman"}
+	// GameModeHardcoreGroupIronman Hardcore Group Ironman game mode
+	GameModeHardcoreGroupIronman GameMode = GameMode{"Hardcore Group Ironman", "_hardcore_group_ironman"}
+	// GameModeFormerHardcoreIronman Ironman who lost Hardcore status. They stay on
+	// the Ironman hiscores, so it shares them
+	GameModeFormerHardcoreIronman GameMode = GameMode{"Former Hardcore Ironman", "_ironman"}
+	// GameModeDeadman Deadman game mode
+	GameModeDeadman GameMode = GameMode{"Deadman", "_deadman"}
+	// GameModeSeasonal Seasonal game mode, used for Leagues
+	GameModeSeasonal GameMode = GameMode{"Seasonal", "_seasonal"}
+	// GameModeTournament Tournament game mode
+	GameModeTournament GameMode = GameMode{"Tournament", "_tournament"}
+	// GameModeFreshStart Fresh Start game mode
+	GameModeFreshStart GameMode = GameMode{"Fresh Start", "_fresh_start"}
+
+	// GameModes Registry of every GameMode with its own hiscores
+	GameModes []GameMode = []GameMode{
+		GameModeNormal,
+		GameModeIronman,
+		GameModeHardcoreIronman,
+		GameModeUltimateIronman,
+		GameModeGroupIronman,
+		GameModeHardcoreGroupIronman,
+		GameModeDeadman,
+		GameModeSeasonal,
+		GameModeTournament,
+		GameModeFreshStart,
+	}
+
+	// Account type hierarchy used to detect the most restrictive GameMode of a
+	// player, mapping each GameMode to the GameModes that restrict it further, in
+	// order of precedence. Deadman, Seasonal, Tournament and Fresh Start are run on
+	// separate worlds with separate accounts, so they are never detected
+	gameModeChildren map[GameMode][]GameMode = map[GameMode][]GameMode{
+		GameModeNormal: []GameMode{
+			GameModeIronman,
+			GameModeGroupIronman,
+		},
+		GameModeIronman: []GameMode{
+			GameModeHardcoreIronman,
+			GameModeUltimateIronman,
+		},
+		GameModeGroupIronman: []GameMode{
+			GameModeHardcoreGroupIronman,
+		},
+	}
+
+	// Maps a GameMode to the status its players can have lost. Once a hardcore
+	// player dies, their hardcore scores freeze while they keep training on the
+	// hiscores of the GameMode
+	lostStatusGameModes map[GameMode]lostStatus = map[GameMode]lostStatus{
+		GameModeIronman: lostStatus{GameModeHardcoreIronman, GameModeFormerHardcoreIronman},
+	}
+)
+
+// errGameModeLeft Returned when a player's scores show they left the GameMode
+// remembered for them
+var errGameModeLeft = errors.New("Player left their game mode")
+
+// gameModeParent Returns the GameMode a player of the given GameMode is also ranked
+// in with the same scores, false if there is none. Players who lost a status are
+// ranked where the GameMode they lost it in is
+func gameModeParent(mode GameMode) (GameMode, bool) {
+	for kept, status := range lostStatusGameModes {
+		if status.former == mode {
+			mode = kept
+		}
+	}
+
+	for parent, children := range gameModeChildren {
+		for _, child := range children {
+			if child == mode {
+				return parent, true
+			}
+		}
+	}
+
+	return GameMode{}, false
+}
+
+// lostStatus Status a player can lose, going from the hardcore GameMode to former
+type lostStatus struct {
+	hardcore GameMode
+	former   GameMode
+}
+
+// LostStatus Returns true if a player detected as previous and now detected as
+// current lost their hardcore status in between
+func LostStatus(previous, current GameMode) bool {
+	for _, status := range lostStatusGameModes {
+		if previous == status.hardcore && current == status.former {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GameMode struct representing the type of account
+type GameMode struct {
+	Name         string
+	urlComponent string
+}
+
+// GetGameModeFromName Maps a display name to its GameMode in the registry, or to
+// the GameMode of a player who lost a status
+func GetGameModeFromName(name string) (GameMode, error) {
+	for _, mode := range GameModes {
+		if strings.EqualFold(mode.Name, name) {
+			return mode, nil
+		}
+	}
+
+	for _, status := range lostStatusGameModes {
+		if strings.EqualFold(status.former.Name, name) {
+			return status.former, nil
+		}
+	}
+
+	return GameMode{}, errors.New("Could not map name to game mode")
+}
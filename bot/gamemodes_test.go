@@ -0,0 +1,81 @@
+package bot
+
+import "testing"
+
+func TestGetGameModeFromName(t *testing.T) {
+	t.Run("ValidName", func(t *testing.T) {
+		mode, err := GetGameModeFromName("hardcore group ironman")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mode != GameModeHardcoreGroupIronman {
+			t.Errorf("Expected %s, got %s", GameModeHardcoreGroupIronman.Name, mode.Name)
+		}
+	})
+
+	t.Run("LostStatus", func(t *testing.T) {
+		mode, err := GetGameModeFromName(GameModeFormerHardcoreIronman.Name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mode != GameModeFormerHardcoreIronman {
+			t.Errorf("Expected %s, got %s", GameModeFormerHardcoreIronman.Name, mode.Name)
+		}
+	})
+
+	t.Run("InvalidName", func(t *testing.T) {
+		if _, err := GetGameModeFromName("Skiller"); err == nil {
+			t.Errorf("Game mode lookup succeeded with invalid name")
+		}
+	})
+}
+
+func TestGameModeHierarchy(t *testing.T) {
+	registered := map[GameMode]bool{}
+	for _, mode := range GameModes {
+		registered[mode] = true
+	}
+
+	// Every GameMode reachable through detection must be in the registry, and
+	// reachable from exactly one parent
+	parents := map[GameMode]GameMode{}
+	for parent, children := range gameModeChildren {
+		if !registered[parent] {
+			t.Errorf("%s is detected but not registered", parent.Name)
+		}
+
+		for _, child := range children {
+			if !registered[child] {
+				t.Errorf("%s is detected but not registered", child.Name)
+			}
+
+			if other, ok := parents[child]; ok {
+				t.Errorf("%s is a child of both %s and %s", child.Name, other.Name, parent.Name)
+			}
+			parents[child] = parent
+		}
+	}
+}
+
+func TestLostStatus(t *testing.T) {
+	testCases := []struct {
+		Previous GameMode
+		Current  GameMode
+		Expected bool
+	}{
+		{GameModeHardcoreIronman, GameModeFormerHardcoreIronman, true},
+		{GameModeHardcoreIronman, GameModeHardcoreIronman, false},
+		{GameModeIronman, GameModeFormerHardcoreIronman, false},
+		{GameModeUltimateIronman, GameModeIronman, false},
+	}
+
+	for _, tc := range testCases {
+		if actual := LostStatus(tc.Previous, tc.Current); actual != tc.Expected {
+			t.Errorf("%s to %s: expected %v, got %v", tc.Previous.Name, tc.Current.Name, tc.Expected, actual)
+		}
+	}
+}
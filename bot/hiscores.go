@@ -1,24 +1,17 @@
 package bot
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 var (
-	// GameModeNormal Normal game mode
-	GameModeNormal GameMode = GameMode{"Normal", ""}
-	// GameModeIronman Ironman game mode
-	GameModeIronman GameMode = GameMode{"Ironman", "_ironman"}
-	// GameModeHardcoreIronman Hardcore Ironman game mode
-	GameModeHardcoreIronman GameMode = GameMode{"Hardcore Ironman", "_hardcore_ironman"}
-	// GameModeUltimateIronman Ultimate Ironman game mode
-	GameModeUltimateIronman GameMode = GameMode{"Ultimate Ironman", "_ultimate"}
-
 	// Skill names concurrent to Hiscores.skills
 	skillNames []string = []string{
 		"Overall",
@@ -57,8 +50,238 @@ var (
 		"Elite",
 		"Master",
 	}
+
+	// Bounty Hunter score names concurrent to the Bounty Hunter rows
+	bountyHunterNames []string = []string{
+		"Hunter",
+		"Rogue",
+	}
+
+	// Boss names in the order they appear on the hiscores, used as the keys
+	// of Hiscores.bosses
+	bossNames []string = []string{
+		"Abyssal Sire",
+		"Alchemical Hydra",
+		"Barrows Chests",
+		"Bryophyta",
+		"Callisto",
+		"Cerberus",
+		"Chambers of Xeric",
+		"Chambers of Xeric: Challenge Mode",
+		"Chaos Elemental",
+		"Chaos Fanatic",
+		"Commander Zilyana",
+		"Corporeal Beast",
+		"Crazy Archaeologist",
+		"Dagannoth Prime",
+		"Dagannoth Rex",
+		"Dagannoth Supreme",
+		"Deranged Archaeologist",
+		"General Graardor",
+		"Giant Mole",
+		"Grotesque Guardians",
+		"Hespori",
+		"Kalphite Queen",
+		"King Black Dragon",
+		"Kraken",
+		"Kree'Arra",
+		"K'ril Tsutsaroth",
+		"Mimic",
+		"Obor",
+		"Sarachnis",
+		"Scorpia",
+		"Skotizo",
+		"The Gauntlet",
+		"The Corrupted Gauntlet",
+		"Theatre of Blood",
+		"Thermonuclear Smoke Devil",
+		"TzKal-Zuk",
+		"TzTok-Jad",
+		"Venenatis",
+		"Vet'ion",
+		"Vorkath",
+		"Wintertodt",
+		"Zalcano",
+		"Zulrah",
+	}
+
+	// Boss aliases mapping to official boss names. Official names are matched
+	// case-insensitively without needing an entry here
+	bossAliases map[string]string = map[string]string{
+		"sire":      "Abyssal Sire",
+		"hydra":     "Alchemical Hydra",
+		"barrows":   "Barrows Chests",
+		"bryo":      "Bryophyta",
+		"cerb":      "Cerberus",
+		"cox":       "Chambers of Xeric",
+		"raids":     "Chambers of Xeric",
+		"raids1":    "Chambers of Xeric",
+		"olm":       "Chambers of Xeric",
+		"coxcm":     "Chambers of Xeric: Challenge Mode",
+		"cm":        "Chambers of Xeric: Challenge Mode",
+		"chaosele":  "Chaos Elemental",
+		"fanatic":   "Chaos Fanatic",
+		"zilyana":   "Commander Zilyana",
+		"zily":      "Commander Zilyana",
+		"sara":      "Commander Zilyana",
+		"corp":      "Corporeal Beast",
+		"prime":     "Dagannoth Prime",
+		"rex":       "Dagannoth Rex",
+		"supreme":   "Dagannoth Supreme",
+		"deranged":  "Deranged Archaeologist",
+		"graardor":  "General Graardor",
+		"bandos":    "General Graardor",
+		"mole":      "Giant Mole",
+		"gg":        "Grotesque Guardians",
+		"ggs":       "Grotesque Guardians",
+		"kq":        "Kalphite Queen",
+		"kbd":       "King Black Dragon",
+		"kree":      "Kree'Arra",
+		"arma":      "Kree'Arra",
+		"kril":      "K'ril Tsutsaroth",
+		"zammy":     "K'ril Tsutsaroth",
+		"skot":      "Skotizo",
+		"gauntlet":  "The Gauntlet",
+		"cg":        "The Corrupted Gauntlet",
+		"corrupted": "The Corrupted Gauntlet",
+		"tob":       "Theatre of Blood",
+		"raids2":    "Theatre of Blood",
+		"thermy":    "Thermonuclear Smoke Devil",
+		"thermo":    "Thermonuclear Smoke Devil",
+		"zuk":       "TzKal-Zuk",
+		"inferno":   "TzKal-Zuk",
+		"jad":       "TzTok-Jad",
+		"vene":      "Venenatis",
+		"vetion":    "Vet'ion",
+		"vork":      "Vorkath",
+		"wt":        "Wintertodt",
+		"todt":      "Wintertodt",
+		"zul":       "Zulrah",
+		"snek":      "Zulrah",
+	}
+
+	// SkillAliases Skill name and alias mapping to Skill
+	SkillAliases map[string]Skill = map[string]Skill{
+		"overall":      SkillOverall,
+		"total":        SkillOverall,
+		"attack":       SkillAttack,
+		"atk":          SkillAttack,
+		"defense":      SkillDefense,
+		"def":          SkillDefense,
+		"strength":     SkillStrength,
+		"str":          SkillStrength,
+		"hitpoints":    SkillHitpoints,
+		"hp":           SkillHitpoints,
+		"ranged":       SkillRanged,
+		"range":        SkillRanged,
+		"ranging":      SkillRanged,
+		"prayer":       SkillPrayer,
+		"pray":         SkillPrayer,
+		"magic":        SkillMagic,
+		"mage":         SkillMagic,
+		"magician":     SkillMagic,
+		"cooking":      SkillCooking,
+		"cook":         SkillCooking,
+		"woodcutting":  SkillWoodcutting,
+		"woodcut":      SkillWoodcutting,
+		"wc":           SkillWoodcutting,
+		"fletching":    SkillFletching,
+		"fletch":       SkillFletching,
+		"fishing":      SkillFishing,
+		"fish":         SkillFishing,
+		"firemaking":   SkillFiremaking,
+		"fm":           SkillFiremaking,
+		"crafting":     SkillCrafting,
+		"craft":        SkillCrafting,
+		"smithing":     SkillSmithing,
+		"smith":        SkillSmithing,
+		"mining":       SkillMining,
+		"mine":         SkillMining,
+		"herblore":     SkillHerblore,
+		"herb":         SkillHerblore,
+		"agility":      SkillAgility,
+		"agil":         SkillAgility,
+		"thieving":     SkillThieving,
+		"thieve":       SkillThieving,
+		"thiev":        SkillThieving,
+		"slayer":       SkillSlayer,
+		"slay":         SkillSlayer,
+		"farming":      SkillFarming,
+		"farm":         SkillFarming,
+		"kkona":        SkillFarming,
+		"runecraft":    SkillRunecraft,
+		"rc":           SkillRunecraft,
+		"hunter":       SkillHunter,
+		"hunting":      SkillHunter,
+		"hunt":         SkillHunter,
+		"construction": SkillConstruction,
+		"con":          SkillConstruction,
+	}
+
+	// Clue score name and alias mapping to Clue
+	clueAliases map[string]Clue = map[string]Clue{
+		"all":      ClueOverallClues,
+		"overall":  ClueOverallClues,
+		"total":    ClueOverallClues,
+		"beginner": ClueBeginnerClues,
+		"beg":      ClueBeginnerClues,
+		"easy":     ClueEasyClues,
+		"medium":   ClueMediumClues,
+		"med":      ClueMediumClues,
+		"hard":     ClueHardClues,
+		"elite":    ClueEliteClues,
+		"master":   ClueMasterClues,
+	}
+
+	// Bounty Hunter score name and alias mapping to the index in bountyHunterNames
+	bountyHunterAliases map[string]int = map[string]int{
+		"hunter":  0,
+		"hunters": 0,
+		"target":  0,
+		"targets": 0,
+		"rogue":   1,
+		"rogues":  1,
+	}
+
+	// DefaultLookupTimeout Default deadline for a single hiscore lookup
+	DefaultLookupTimeout time.Duration = 10 * time.Second
+
+	// DefaultModeTTL Default duration a player's detected GameMode is remembered for
+	DefaultModeTTL time.Duration = 30 * time.Minute
+
+	// HiscoreBaseURL Host serving the OSRS Hiscore API
+	HiscoreBaseURL string = "https://secure.runescape.com"
+
+	// Layout of the rows returned by the OSRS Hiscore API, in order. When Jagex
+	// adds, removes or reorders rows, this table is the only thing to update
+	hiscoreLayout []hiscoreSection = []hiscoreSection{
+		{groupSkill, skillNames},
+		{groupBountyHunter, bountyHunterNames},
+		{groupLMS, []string{"Last Man Standing"}},
+		{groupClue, clueNames},
+		{groupBoss, bossNames},
+	}
 )
 
+// hiscoreGroup Enum value for the part of Hiscores a hiscore row maps to
+type hiscoreGroup int
+
+// Enumerated values for the groups of rows in the hiscore layout
+const (
+	groupSkill hiscoreGroup = iota
+	groupBountyHunter
+	groupLMS
+	groupClue
+	groupBoss
+)
+
+// hiscoreSection A contiguous run of hiscore rows belonging to the same group,
+// named in the order they appear
+type hiscoreSection struct {
+	group hiscoreGroup
+	names []string
+}
+
 // Skill Enum value for skill
 type Skill int
 
@@ -101,17 +324,11 @@ const (
 	ClueMasterClues   Clue = 6
 )
 
-// GameMode struct representing the type of account
-type GameMode struct {
-	Name         string
-	urlComponent string
-}
-
 // SkillHiscore struct representing the hiscore of a single skill
 type SkillHiscore struct {
-	Rank  int
-	Level int
-	Exp   int
+	Rank  int `json:"rank"`
+	Level int `json:"level"`
+	Exp   int `json:"exp"`
 }
 
 // MinigameHiscore struct representing the hiscore of anything that's not a skill
@@ -130,17 +347,33 @@ type Hiscores struct {
 	lms MinigameHiscore
 
 	clues []MinigameHiscore
+
+	bosses map[string]MinigameHiscore
 }
 
 // UnrankedError Returned when a hiscore doesn't exist (player is unranked)
 type UnrankedError struct{}
 
-// HiscoreAPIError Returned when a player's hiscores aren't available for the given GameMode
+// HiscoreAPIError Returned when a player doesn't exist on the hiscores for the given GameMode
 type HiscoreAPIError struct {
 	Player string
 	Mode   GameMode
 }
 
+// HiscoreLayoutError Returned when a hiscore response doesn't match the expected
+// layout, either in its number of rows or in the contents of a row
+type HiscoreLayoutError struct {
+	Expected int
+	Actual   int
+
+	Row   int
+	Entry string
+	Value string
+
+	// Missing Entry the response doesn't have at all, for responses matched by name
+	Missing string
+}
+
 func (e *UnrankedError) Error() string {
 	return "Player is not ranked in this skill/minigame"
 }
@@ -149,55 +382,94 @@ func (e *HiscoreAPIError) Error() string {
 	return fmt.Sprintf("%s is not a(n) %s account", e.Player, e.Mode.Name)
 }
 
+func (e *HiscoreLayoutError) Error() string {
+	if e.Missing != "" {
+		return fmt.Sprintf("hiscore layout changed: %s is missing", e.Missing)
+	}
+
+	if e.Entry == "" {
+		return fmt.Sprintf("hiscore layout changed: expected %d rows, found %d", e.Expected, e.Actual)
+	}
+
+	return fmt.Sprintf("hiscore layout changed: row %d (%s) is malformed: %q", e.Row, e.Entry, e.Value)
+}
+
 // HiscoreAPIClient Interface to abstract from any interactions with the Hiscore API
 type HiscoreAPIClient interface {
-	GetAPIResponse(player string, mode GameMode) (string, error)
+	GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error)
+	ParseHiscores(response string) (Hiscores, error)
 }
 
 // OSRSHiscoreAPIClient Implementation of HiscoreAPIClient that queries the OSRS Hiscore API
-type OSRSHiscoreAPIClient struct{}
+type OSRSHiscoreAPIClient struct {
+	HTTP HiscoreHTTPClient
+}
 
 // HiscoreAPI Directs all features that interface with the Hiscore API
 type HiscoreAPI struct {
 	Client HiscoreAPIClient
-}
 
-// GetAPIResponse Sends a GET request to the OSRS Hiscore API according to the
-// player and GameMode, and returns the response as a CSV string
-func (osrsAPI *OSRSHiscoreAPIClient) GetAPIResponse(player string, mode GameMode) (string, error) {
-	url := FormatHiscoreAPIURL(player, mode)
+	// Timeout Deadline shared by every request made for a single lookup. No
+	// deadline is set if zero
+	Timeout time.Duration
 
-	resp, err := http.Get(url)
+	// ModeTTL How long the GameMode detected for a player is remembered for. While
+	// remembered, lookups of the player only query that GameMode. Nothing is
+	// remembered if zero
+	ModeTTL time.Duration
 
-	if err != nil {
-		return "", err
-	}
+	modesMutex sync.Mutex
+	modes      map[string]detectedGameMode
+}
 
-	defer resp.Body.Close()
+// detectedGameMode GameMode detected for a player, and when it expires
+type detectedGameMode struct {
+	mode    GameMode
+	expires time.Time
+}
 
-	// Any other status code means the player does not exist in the given mode
-	if resp.StatusCode != 200 {
-		return "", &HiscoreAPIError{player, mode}
-	}
+// gameModeResult Result of looking up a player's hiscores in a single GameMode
+type gameModeResult struct {
+	mode     GameMode
+	hiscores Hiscores
+	err      error
+}
+
+// GetAPIResponse Sends a GET request to the OSRS Hiscore API according to the
+// player and GameMode, and returns the response as a CSV string
+func (osrsAPI *OSRSHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	return osrsAPI.HTTP.Get(ctx, FormatHiscoreAPIURL(player, mode), player, mode)
+}
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	return string(bodyBytes), err
+// ParseHiscores Parses a CSV response from the OSRS Hiscore API into Hiscores
+func (osrsAPI *OSRSHiscoreAPIClient) ParseHiscores(response string) (Hiscores, error) {
+	return parseCSVHiscores(response)
 }
 
 // FormatHiscoreAPIURL Formats the base URL used to request hiscores from the OSRS Hiscore API
 // based on the GameMode and adds the player as a query param
 func FormatHiscoreAPIURL(player string, mode GameMode) string {
+	return formatHiscoreURL(HiscoreBaseURL, "index_lite.ws", player, mode)
+}
+
+// formatHiscoreURL Formats the URL of a Hiscore API endpoint on the given host based
+// on the GameMode and adds the player as an escaped query param
+func formatHiscoreURL(baseURL, endpoint, player string, mode GameMode) string {
 	return fmt.Sprintf(
-		"https://secure.runescape.com/m=hiscore_oldschool%s/index_lite.ws?player=%s",
+		"%s/m=hiscore_oldschool%s/%s?player=%s",
+		baseURL,
 		mode.urlComponent,
-		player,
+		endpoint,
+		url.QueryEscape(player),
 	)
 }
 
 // NewOSRSHiscoreAPI Returns a Hiscore API with the OSRS API client implementation
 func NewOSRSHiscoreAPI() *HiscoreAPI {
 	return &HiscoreAPI{
-		Client: &OSRSHiscoreAPIClient{},
+		Client:  &OSRSHiscoreAPIClient{HTTP: NewHiscoreHTTPClient()},
+		Timeout: DefaultLookupTimeout,
+		ModeTTL: DefaultModeTTL,
 	}
 }
 
@@ -218,161 +490,431 @@ func SameScores(h1, h2 Hiscores) bool {
 // For example, a Hardcore Ironman has a Normal, Ironman, and Hardcore Ironman
 // hiscore entry, but here we only return the Hardcore Ironman entry
 func (api *HiscoreAPI) LookupHiscores(player string) (Hiscores, GameMode, error) {
-	mode := GameModeNormal
-	playerHiscores, err := api.LookupHiscoresByGameMode(player, mode)
+	return api.LookupHiscoresContext(context.Background(), player)
+}
+
+// LookupHiscoresContext Same as LookupHiscores, but all requests to the Hiscore API
+// are canceled along with ctx
+//
+// If the player's GameMode was detected within ModeTTL, only that GameMode and its
+// parent are queried. Otherwise every GameMode in the account type hierarchy is queried in
+// parallel, and the most restrictive one is detected from the results
+func (api *HiscoreAPI) LookupHiscoresContext(ctx context.Context, player string) (Hiscores, GameMode, error) {
+	if api.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, api.Timeout)
+		defer cancel()
+	}
+
+	if mode, ok := api.rememberedGameMode(player); ok {
+		playerHiscores, err := api.lookupRememberedGameMode(ctx, player, mode)
+		if err == nil {
+			return playerHiscores, mode, nil
+		}
+
+		// The player may have left the GameMode since, so detect it again
+		api.forgetGameMode(player)
+	}
+
+	results, err := api.lookupAllGameModes(ctx, player)
 
 	// All players should be in normal hiscores. If they are not, they are either unranked
 	// or they do not exist
 	if err != nil {
-		return playerHiscores, mode, err
-	}
-
-	ironmanHiscores, err := api.LookupHiscoresByGameMode(player, GameModeIronman)
-	if err == nil {
-		hardcoreHiscores, err := api.LookupHiscoresByGameMode(player, GameModeHardcoreIronman)
-		ultimateHiscores, err2 := api.LookupHiscoresByGameMode(player, GameModeUltimateIronman)
-
-		// To determine what kind of ironman we have, first we check to see if there is a
-		// hiscore under that GameMode. If experience values in that GameMode and
-		// ironman mode match, the player is in that GameMode
-		if err == nil && SameScores(hardcoreHiscores, ironmanHiscores) {
-			playerHiscores = hardcoreHiscores
-			mode = GameModeHardcoreIronman
-		} else if err2 == nil && SameScores(ultimateHiscores, ironmanHiscores) {
-			playerHiscores = ultimateHiscores
-			mode = GameModeUltimateIronman
-		} else {
-			playerHiscores = ironmanHiscores
-			mode = GameModeIronman
-		}
+		return Hiscores{}, GameModeNormal, err
 	}
 
+	playerHiscores, mode := detectGameMode(results)
+	api.rememberGameMode(player, mode)
+
 	return playerHiscores, mode, nil
 }
 
+// lookupRememberedGameMode Looks up the player in the GameMode remembered for them
+// and in its parent GameMode in parallel. Players can leave a GameMode without
+// leaving its hiscores, like a Hardcore Ironman that died, whose hardcore scores
+// freeze while they keep training. So the GameMode is only trusted while its
+// scores still match the parent's, and errGameModeLeft is returned otherwise
+func (api *HiscoreAPI) lookupRememberedGameMode(ctx context.Context, player string, mode GameMode) (Hiscores, error) {
+	parent, ok := gameModeParent(mode)
+	if !ok {
+		return api.LookupHiscoresByGameModeContext(ctx, player, mode)
+	}
+
+	parentChan := make(chan gameModeResult, 1)
+	go func() {
+		parentHiscores, err := api.LookupHiscoresByGameModeContext(ctx, player, parent)
+		parentChan <- gameModeResult{parent, parentHiscores, err}
+	}()
+
+	playerHiscores, err := api.LookupHiscoresByGameModeContext(ctx, player, mode)
+	parentResult := <-parentChan
+
+	switch {
+	case err != nil:
+		return Hiscores{}, err
+	case parentResult.err != nil:
+		return Hiscores{}, parentResult.err
+	case !SameScores(playerHiscores, parentResult.hiscores):
+		return Hiscores{}, errGameModeLeft
+	}
+
+	return playerHiscores, nil
+}
+
+// lookupAllGameModes Looks up the player in every GameMode of the account type
+// hierarchy in parallel. If the Normal lookup fails, or any lookup fails for a
+// reason other than the player not existing in that GameMode, the remaining
+// lookups are canceled and the error is returned
+func (api *HiscoreAPI) lookupAllGameModes(ctx context.Context, player string) (map[GameMode]gameModeResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	modes := []GameMode{GameModeNormal}
+	for i := 0; i < len(modes); i++ {
+		modes = append(modes, gameModeChildren[modes[i]]...)
+	}
+
+	// Buffered so that lookups finishing after a cancellation don't block forever
+	resultChan := make(chan gameModeResult, len(modes))
+	for _, mode := range modes {
+		go func(mode GameMode) {
+			playerHiscores, err := api.LookupHiscoresByGameModeContext(ctx, player, mode)
+			resultChan <- gameModeResult{mode, playerHiscores, err}
+		}(mode)
+	}
+
+	results := make(map[GameMode]gameModeResult, len(modes))
+	for range modes {
+		result := <-resultChan
+
+		// Not existing in a restrictive GameMode is expected. Any other failure
+		// means the GameMode can't be detected accurately
+		if _, notFound := result.err.(*HiscoreAPIError); result.err != nil && (result.mode == GameModeNormal || !notFound) {
+			return nil, result.err
+		}
+
+		results[result.mode] = result
+	}
+
+	return results, nil
+}
+
+// detectGameMode Walks down the account type hierarchy from the Normal GameMode,
+// returning the most restrictive GameMode the player belongs to along with the
+// hiscores ranked in it
+//
+// To determine whether the player belongs to a child GameMode, first we check to
+// see if there is a hiscore under that GameMode. If experience values in that
+// GameMode and the parent GameMode match, the player is in that GameMode. This
+// rules out accounts that left a GameMode, like a Hardcore Ironman that died,
+// which are reported in the GameMode for their lost status instead
+func detectGameMode(results map[GameMode]gameModeResult) (Hiscores, GameMode) {
+	current := results[GameModeNormal]
+
+	for {
+		next, found := current, false
+
+		for _, child := range gameModeChildren[current.mode] {
+			result, ok := results[child]
+			if ok && result.err == nil && SameScores(result.hiscores, current.hiscores) {
+				next, found = result, true
+				break
+			}
+		}
+
+		if !found {
+			// Still being on the hardcore hiscores with different scores means the
+			// player lost their status and kept playing
+			if status, ok := lostStatusGameModes[current.mode]; ok {
+				if result, ok := results[status.hardcore]; ok && result.err == nil {
+					return current.hiscores, status.former
+				}
+			}
+
+			return current.hiscores, current.mode
+		}
+
+		current = next
+	}
+}
+
+// rememberedGameMode Returns the GameMode detected for the player, if it hasn't expired
+func (api *HiscoreAPI) rememberedGameMode(player string) (GameMode, bool) {
+	api.modesMutex.Lock()
+	defer api.modesMutex.Unlock()
+
+	detected, ok := api.modes[strings.ToLower(player)]
+	if !ok || time.Now().After(detected.expires) {
+		return GameMode{}, false
+	}
+
+	return detected.mode, true
+}
+
+// rememberGameMode Remembers the GameMode detected for the player for ModeTTL
+func (api *HiscoreAPI) rememberGameMode(player string, mode GameMode) {
+	if api.ModeTTL <= 0 {
+		return
+	}
+
+	api.modesMutex.Lock()
+	defer api.modesMutex.Unlock()
+
+	if api.modes == nil {
+		api.modes = map[string]detectedGameMode{}
+	}
+
+	// Drop expired entries so players that are never looked up again don't pile up
+	now := time.Now()
+	for key, detected := range api.modes {
+		if now.After(detected.expires) {
+			delete(api.modes, key)
+		}
+	}
+
+	api.modes[strings.ToLower(player)] = detectedGameMode{mode, now.Add(api.ModeTTL)}
+}
+
+// forgetGameMode Forgets the GameMode detected for the player
+func (api *HiscoreAPI) forgetGameMode(player string) {
+	api.modesMutex.Lock()
+	defer api.modesMutex.Unlock()
+
+	delete(api.modes, strings.ToLower(player))
+}
+
 // GetSkillHiscoreFromName maps string name to a specific hiscore, returns that score
-// with its official name
-func (hiscores Hiscores) GetSkillHiscoreFromName(name string) (string, SkillHiscore, error) {
-	// Skill name and alias mapping to individual skill hiscores
-	skillMap := map[string]Skill{
-		"overall":      SkillOverall,
-		"total":        SkillOverall,
-		"attack":       SkillAttack,
-		"atk":          SkillAttack,
-		"defense":      SkillDefense,
-		"def":          SkillDefense,
-		"strength":     SkillStrength,
-		"str":          SkillStrength,
-		"hitpoints":    SkillHitpoints,
-		"hp":           SkillHitpoints,
-		"ranged":       SkillRanged,
-		"range":        SkillRanged,
-		"ranging":      SkillRanged,
-		"prayer":       SkillPrayer,
-		"pray":         SkillPrayer,
-		"magic":        SkillMagic,
-		"mage":         SkillMagic,
-		"magician":     SkillMagic,
-		"cooking":      SkillCooking,
-		"cook":         SkillCooking,
-		"woodcutting":  SkillWoodcutting,
-		"woodcut":      SkillWoodcutting,
-		"wc":           SkillWoodcutting,
-		"fletching":    SkillFletching,
-		"fletch":       SkillFletching,
-		"fishing":      SkillFishing,
-		"fish":         SkillFishing,
-		"firemaking":   SkillFiremaking,
-		"fm":           SkillFiremaking,
-		"crafting":     SkillCrafting,
-		"craft":        SkillCrafting,
-		"smithing":     SkillSmithing,
-		"smith":        SkillSmithing,
-		"mining":       SkillMining,
-		"mine":         SkillMining,
-		"herblore":     SkillHerblore,
-		"herb":         SkillHerblore,
-		"agility":      SkillAgility,
-		"agil":         SkillAgility,
-		"thieving":     SkillThieving,
-		"thieve":       SkillThieving,
-		"thiev":        SkillThieving,
-		"slayer":       SkillSlayer,
-		"slay":         SkillSlayer,
-		"farming":      SkillFarming,
-		"farm":         SkillFarming,
-		"kkona":        SkillFarming,
-		"runecraft":    SkillRunecraft,
-		"rc":           SkillRunecraft,
-		"hunter":       SkillHunter,
-		"hunting":      SkillHunter,
-		"hunt":         SkillHunter,
-		"construction": SkillConstruction,
-		"con":          SkillConstruction,
+// with its official name. Names are matched with MatchSkillWithAliases, so custom
+// aliases come first, and typos are corrected when there's only one skill they
+// could be
+func (hiscores Hiscores) GetSkillHiscoreFromName(name string, aliases map[string]string) (string, SkillHiscore, error) {
+	skill, err := MatchSkillWithAliases(name, aliases)
+
+	if err != nil {
+		return "", SkillHiscore{}, err
+	}
+
+	return skillNames[skill], hiscores.skills[skill], nil
+}
+
+// GetBossHiscoreFromName maps string name to a specific boss hiscore, returns that
+// score with its official name. Returns UnrankedError if the player has no kill
+// count ranked for the boss
+func (hiscores Hiscores) GetBossHiscoreFromName(name string) (string, MinigameHiscore, error) {
+	bossName, ok := bossAliases[strings.ToLower(name)]
+
+	if !ok {
+		for _, official := range bossNames {
+			if strings.EqualFold(official, name) {
+				bossName, ok = official, true
+				break
+			}
+		}
 	}
 
-	if skill, ok := skillMap[strings.ToLower(name)]; ok {
-		return skillNames[skill], hiscores.skills[skill], nil
+	if !ok {
+		return "", MinigameHiscore{}, errors.New("Could not map name to boss")
 	}
 
-	return "", SkillHiscore{}, errors.New("Could not map name to skill")
+	boss := hiscores.bosses[bossName]
+	return bossName, boss, rankedMinigameHiscore(boss)
 }
 
-func parseSkillHiscore(hiscore string) (SkillHiscore, error) {
-	skill := SkillHiscore{}
+// GetClueHiscoreFromName maps string name to a specific clue scroll hiscore, returns
+// that score with its official name. Returns UnrankedError if the player has no
+// clue scrolls ranked for the tier
+func (hiscores Hiscores) GetClueHiscoreFromName(name string) (string, MinigameHiscore, error) {
+	clue, ok := clueAliases[strings.ToLower(name)]
+
+	if !ok {
+		return "", MinigameHiscore{}, errors.New("Could not map name to clue scroll tier")
+	}
+
+	return clueNames[clue], hiscores.clues[clue], rankedMinigameHiscore(hiscores.clues[clue])
+}
+
+// GetBountyHunterHiscoreFromName maps string name to a specific Bounty Hunter
+// hiscore, returns that score with its official name. Returns UnrankedError if the
+// player has no score ranked for it
+func (hiscores Hiscores) GetBountyHunterHiscoreFromName(name string) (string, MinigameHiscore, error) {
+	i, ok := bountyHunterAliases[strings.ToLower(name)]
+
+	if !ok {
+		return "", MinigameHiscore{}, errors.New("Could not map name to Bounty Hunter score")
+	}
+
+	bountyHunter := hiscores.bhHunter
+	if i == 1 {
+		bountyHunter = hiscores.bhRogue
+	}
+
+	return bountyHunterNames[i], bountyHunter, rankedMinigameHiscore(bountyHunter)
+}
+
+// LastManStanding Returns the Last Man Standing hiscore. Returns UnrankedError if
+// the player has no score ranked for it
+func (hiscores Hiscores) LastManStanding() (MinigameHiscore, error) {
+	return hiscores.lms, rankedMinigameHiscore(hiscores.lms)
+}
+
+// rankedMinigameHiscore Returns UnrankedError if the minigame hiscore is unranked.
+// Unranked minigame hiscores are parsed as the zero value, and no ranked hiscore
+// has a rank of 0
+func rankedMinigameHiscore(minigame MinigameHiscore) error {
+	if minigame.Rank == 0 {
+		return &UnrankedError{}
+	}
+
+	return nil
+}
+
+// parseHiscoreFields Splits a hiscore row into exactly n integer fields
+func parseHiscoreFields(hiscore string, n int) ([]int, error) {
 	vals := strings.Split(hiscore, ",")
-	skill.Rank, _ = strconv.Atoi(vals[0])
-	skill.Level, _ = strconv.Atoi(vals[1])
-	skill.Exp, _ = strconv.Atoi(vals[2])
-	return skill, nil
+	if len(vals) != n {
+		return nil, fmt.Errorf("expected %d fields, found %d", n, len(vals))
+	}
+
+	fields := make([]int, n)
+	for i, val := range vals {
+		field, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = field
+	}
+
+	return fields, nil
+}
+
+func parseSkillHiscore(hiscore string) (SkillHiscore, error) {
+	fields, err := parseHiscoreFields(hiscore, 3)
+	if err != nil {
+		return SkillHiscore{}, err
+	}
+
+	return SkillHiscore{
+		Rank:  fields[0],
+		Level: fields[1],
+		Exp:   fields[2],
+	}, nil
 }
 
 func parseMinigameHiscore(hiscore string) (MinigameHiscore, error) {
-	minigame := MinigameHiscore{}
+	fields, err := parseHiscoreFields(hiscore, 2)
+	if err != nil {
+		return MinigameHiscore{}, err
+	}
 
 	// When a player is unranked, the result is -1,-1
-	if vals := strings.Split(hiscore, ","); vals[0] != "-1" {
-		minigame.Rank, _ = strconv.Atoi(vals[0])
-		minigame.Score, _ = strconv.Atoi(vals[1])
-		return minigame, nil
+	if fields[0] == -1 {
+		return MinigameHiscore{}, &UnrankedError{}
 	}
 
-	return minigame, &UnrankedError{}
+	return MinigameHiscore{
+		Rank:  fields[0],
+		Score: fields[1],
+	}, nil
 }
 
-func parseCSVHiscores(hiscoreCSV string) Hiscores {
-	hiscores := Hiscores{}
-	allScores := strings.Fields(hiscoreCSV)
+// newHiscores Returns Hiscores with every score allocated and unranked
+func newHiscores() Hiscores {
+	hiscores := Hiscores{
+		skills: make([]SkillHiscore, len(skillNames)),
+		clues:  make([]MinigameHiscore, len(clueNames)),
+		bosses: make(map[string]MinigameHiscore, len(bossNames)),
+	}
 
-	// Skill mappings
-	hiscores.skills = make([]SkillHiscore, 24)
-	for i, skill := range allScores[:24] {
-		hiscores.skills[i], _ = parseSkillHiscore(skill)
+	for _, boss := range bossNames {
+		hiscores.bosses[boss] = MinigameHiscore{}
 	}
 
-	// Bounty Hunter mappings
-	hiscores.bhHunter, _ = parseMinigameHiscore(allScores[24])
-	hiscores.bhRogue, _ = parseMinigameHiscore(allScores[25])
+	return hiscores
+}
 
-	// LMS mapping
-	hiscores.lms, _ = parseMinigameHiscore(allScores[26])
+// setMinigameHiscore Sets the score of the i-th entry, named name, of the given
+// non-skill group
+func (hiscores *Hiscores) setMinigameHiscore(group hiscoreGroup, i int, name string, minigame MinigameHiscore) {
+	switch group {
+	case groupBountyHunter:
+		if i == 0 {
+			hiscores.bhHunter = minigame
+		} else {
+			hiscores.bhRogue = minigame
+		}
+	case groupLMS:
+		hiscores.lms = minigame
+	case groupClue:
+		hiscores.clues[i] = minigame
+	case groupBoss:
+		hiscores.bosses[name] = minigame
+	}
+}
 
-	// Clue mappings
-	hiscores.clues = make([]MinigameHiscore, 7)
-	for i, clue := range allScores[27:34] {
-		hiscores.clues[i], _ = parseMinigameHiscore(clue)
+// layoutRowCount Returns the number of rows the hiscore layout expects
+func layoutRowCount(layout []hiscoreSection) int {
+	count := 0
+	for _, section := range layout {
+		count += len(section.names)
 	}
 
-	return hiscores
+	return count
+}
+
+// parseCSVHiscores Maps the rows of a hiscore CSV response onto Hiscores according
+// to hiscoreLayout. Returns HiscoreLayoutError if the response doesn't fit the layout
+func parseCSVHiscores(hiscoreCSV string) (Hiscores, error) {
+	allScores := strings.Fields(hiscoreCSV)
+
+	if expected := layoutRowCount(hiscoreLayout); len(allScores) != expected {
+		return Hiscores{}, &HiscoreLayoutError{Expected: expected, Actual: len(allScores)}
+	}
+
+	hiscores := newHiscores()
+
+	row := 0
+	for _, section := range hiscoreLayout {
+		for i, name := range section.names {
+			var err error
+
+			if section.group == groupSkill {
+				hiscores.skills[i], err = parseSkillHiscore(allScores[row])
+			} else {
+				var minigame MinigameHiscore
+				minigame, err = parseMinigameHiscore(allScores[row])
+				hiscores.setMinigameHiscore(section.group, i, name, minigame)
+			}
+
+			// Being unranked is expected, anything else means the row is malformed
+			if _, ok := err.(*UnrankedError); err != nil && !ok {
+				return Hiscores{}, &HiscoreLayoutError{
+					Row:   row,
+					Entry: name,
+					Value: allScores[row],
+				}
+			}
+
+			row++
+		}
+	}
+
+	return hiscores, nil
 }
 
 // LookupHiscoresByGameMode Looks up a player's hiscores ranked according to the given GameMode
 func (api *HiscoreAPI) LookupHiscoresByGameMode(player string, mode GameMode) (Hiscores, error) {
-	csv, err := api.Client.GetAPIResponse(player, mode)
+	return api.LookupHiscoresByGameModeContext(context.Background(), player, mode)
+}
+
+// LookupHiscoresByGameModeContext Same as LookupHiscoresByGameMode, but the request
+// to the Hiscore API is canceled along with ctx
+func (api *HiscoreAPI) LookupHiscoresByGameModeContext(ctx context.Context, player string, mode GameMode) (Hiscores, error) {
+	response, err := api.Client.GetAPIResponse(ctx, player, mode)
 
 	if err != nil {
 		return Hiscores{}, err
 	}
 
-	return parseCSVHiscores(csv), nil
+	return api.Client.ParseHiscores(response)
 }
@@ -0,0 +1,215 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen Kept in the HiscoresUnavailableError returned by a
+// CircuitBreakerHiscoreAPIClient that isn't letting requests through
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState Enum value for the state of a circuit breaker
+type BreakerState int
+
+// Enumerated values for the states of a circuit breaker
+const (
+	// BreakerClosed Requests go through as normal
+	BreakerClosed BreakerState = iota
+	// BreakerOpen Requests fail immediately without reaching the Hiscore API
+	BreakerOpen
+	// BreakerHalfOpen A single probe request is let through to check for recovery,
+	// while the rest wait for its outcome
+	BreakerHalfOpen
+)
+
+func (state BreakerState) String() string {
+	switch state {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerStatus Snapshot of a circuit breaker, as reported by the HTTP API
+type BreakerStatus struct {
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	OpenedAt            time.Time `json:"openedAt,omitempty"`
+}
+
+// CircuitBreakerHiscoreAPIClient Implementation of HiscoreAPIClient that stops
+// sending requests through another HiscoreAPIClient while the Hiscore API is down
+//
+// After FailureThreshold consecutive requests fail because the Hiscore API is
+// unavailable, the breaker opens and every request fails immediately with
+// HiscoresUnavailableError. Once OpenTimeout passes, the breaker goes half-open and
+// lets a single probe request through: if it succeeds the breaker closes again,
+// otherwise it stays open for another OpenTimeout. Requests made while the probe
+// runs wait for its outcome, so a lookup querying several GameModes at once isn't
+// failed by its own parallel requests
+type CircuitBreakerHiscoreAPIClient struct {
+	Client           HiscoreAPIClient
+	FailureThreshold int
+	OpenTimeout      time.Duration
+
+	mutex    sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+
+	// probe Closed once the running probe request finishes, nil if none is running
+	probe chan struct{}
+}
+
+// NewCircuitBreakerHiscoreAPIClient Returns a closed CircuitBreakerHiscoreAPIClient
+// wrapping client
+func NewCircuitBreakerHiscoreAPIClient(client HiscoreAPIClient, failureThreshold int, openTimeout time.Duration) *CircuitBreakerHiscoreAPIClient {
+	return &CircuitBreakerHiscoreAPIClient{
+		Client:           client,
+		FailureThreshold: failureThreshold,
+		OpenTimeout:      openTimeout,
+	}
+}
+
+// GetAPIResponse Forwards the request to the wrapped client if the breaker allows it
+func (breaker *CircuitBreakerHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	probe, err := breaker.allow(ctx)
+	if err == ErrCircuitOpen {
+		return "", &HiscoresUnavailableError{Mode: mode, Err: ErrCircuitOpen}
+	} else if err != nil {
+		return "", err
+	}
+
+	response, err := breaker.Client.GetAPIResponse(ctx, player, mode)
+	breaker.record(err, probe)
+
+	return response, err
+}
+
+// ParseHiscores Parses the response with the wrapped client
+func (breaker *CircuitBreakerHiscoreAPIClient) ParseHiscores(response string) (Hiscores, error) {
+	return breaker.Client.ParseHiscores(response)
+}
+
+// Unwrap Returns the wrapped client
+func (breaker *CircuitBreakerHiscoreAPIClient) Unwrap() HiscoreAPIClient {
+	return breaker.Client
+}
+
+// Status Returns the current status of the breaker
+func (breaker *CircuitBreakerHiscoreAPIClient) Status() BreakerStatus {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	return BreakerStatus{
+		State:               breaker.state.String(),
+		ConsecutiveFailures: breaker.failures,
+		OpenedAt:            breaker.openedAt,
+	}
+}
+
+// allow Returns whether the request is the probe of a half-open breaker, or
+// ErrCircuitOpen if it may not go through. An open breaker moves to half-open once
+// OpenTimeout has passed. While a probe runs, waits for its outcome, returning
+// ctx's error if ctx is done first
+func (breaker *CircuitBreakerHiscoreAPIClient) allow(ctx context.Context) (bool, error) {
+	for {
+		breaker.mutex.Lock()
+
+		if breaker.state == BreakerOpen && time.Since(breaker.openedAt) >= breaker.OpenTimeout {
+			breaker.transition(BreakerHalfOpen)
+		}
+
+		switch breaker.state {
+		case BreakerClosed:
+			breaker.mutex.Unlock()
+			return false, nil
+		case BreakerHalfOpen:
+			if breaker.probe == nil {
+				breaker.probe = make(chan struct{})
+				breaker.mutex.Unlock()
+				return true, nil
+			}
+		default:
+			breaker.mutex.Unlock()
+			return false, ErrCircuitOpen
+		}
+
+		probe := breaker.probe
+		breaker.mutex.Unlock()
+
+		select {
+		case <-probe:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// record Updates the breaker with the outcome of a request it let through
+func (breaker *CircuitBreakerHiscoreAPIClient) record(err error, probe bool) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	// Waiting requests check the state again once the mutex is released
+	if probe {
+		close(breaker.probe)
+		breaker.probe = nil
+	}
+
+	// Requests canceled by their caller say nothing about the Hiscore API
+	if err == context.Canceled {
+		return
+	}
+
+	if !isHiscoreOutage(err) {
+		if probe {
+			breaker.transition(BreakerClosed)
+		}
+
+		breaker.failures = 0
+		return
+	}
+
+	breaker.failures++
+	if probe || (breaker.state == BreakerClosed && breaker.failures >= breaker.FailureThreshold) {
+		breaker.openedAt = time.Now()
+		breaker.transition(BreakerOpen)
+	}
+}
+
+// transition Moves the breaker to the given state. Must be called with the mutex held
+func (breaker *CircuitBreakerHiscoreAPIClient) transition(state BreakerState) {
+	if state == breaker.state {
+		return
+	}
+
+	log.Printf(
+		"Hiscore circuit breaker %s -> %s after %d consecutive failures",
+		breaker.state,
+		state,
+		breaker.failures,
+	)
+	breaker.state = state
+}
+
+// isHiscoreOutage Returns true if err means the Hiscore API couldn't answer, as
+// opposed to answering that the player doesn't exist. A lookup running out of
+// time means the Hiscore API is too slow to answer
+func isHiscoreOutage(err error) bool {
+	if err == context.DeadlineExceeded {
+		return true
+	}
+
+	_, ok := err.(*HiscoresUnavailableError)
+	return ok
+}
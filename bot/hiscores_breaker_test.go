@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// unavailableHiscoreAPIClient Fails every request as if the Hiscore API were down,
+// until it's brought back up. Once up, requests wait for gate to close if it's set
+type unavailableHiscoreAPIClient struct {
+	countingHiscoreAPIClient
+	up   int32
+	gate chan struct{}
+}
+
+func (client *unavailableHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	if atomic.LoadInt32(&client.up) == 0 {
+		atomic.AddInt32(&client.calls, 1)
+		return "", &HiscoresUnavailableError{Mode: mode, StatusCode: http.StatusServiceUnavailable}
+	}
+
+	if client.gate != nil {
+		<-client.gate
+	}
+
+	return client.countingHiscoreAPIClient.GetAPIResponse(ctx, player, mode)
+}
+
+func newUnavailableHiscoreAPIClient() *unavailableHiscoreAPIClient {
+	return &unavailableHiscoreAPIClient{
+		countingHiscoreAPIClient: countingHiscoreAPIClient{HiscoreAPIClient: &mockHiscoreAPIClient{}},
+	}
+}
+
+func TestCircuitBreakerHiscoreAPIClient(t *testing.T) {
+	ctx := context.Background()
+	threshold := 3
+
+	t.Run("OpensAfterThreshold", func(t *testing.T) {
+		client := newUnavailableHiscoreAPIClient()
+		breaker := NewCircuitBreakerHiscoreAPIClient(client, threshold, time.Minute)
+
+		for i := 0; i < threshold+2; i++ {
+			_, err := breaker.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+			if !isHiscoreOutage(err) {
+				t.Fatalf("Expected outage error, got %v", err)
+			}
+		}
+
+		if calls := atomic.LoadInt32(&client.calls); calls != int32(threshold) {
+			t.Errorf("Expected %d upstream requests, got %d", threshold, calls)
+		}
+
+		if state := breaker.Status().State; state != BreakerOpen.String() {
+			t.Errorf("Expected breaker to be %s, got %s", BreakerOpen, state)
+		}
+	})
+
+	t.Run("NotFoundIsNotFailure", func(t *testing.T) {
+		client := &countingHiscoreAPIClient{HiscoreAPIClient: &mockHiscoreAPIClient{}}
+		breaker := NewCircuitBreakerHiscoreAPIClient(client, threshold, time.Minute)
+
+		for i := 0; i < threshold+2; i++ {
+			breaker.GetAPIResponse(ctx, notAnAccount, GameModeNormal)
+		}
+
+		if state := breaker.Status().State; state != BreakerClosed.String() {
+			t.Errorf("Expected breaker to be %s, got %s", BreakerClosed, state)
+		}
+	})
+
+	t.Run("Recovers", func(t *testing.T) {
+		client := newUnavailableHiscoreAPIClient()
+		breaker := NewCircuitBreakerHiscoreAPIClient(client, threshold, 10*time.Millisecond)
+
+		for i := 0; i < threshold; i++ {
+			breaker.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+		}
+
+		// The probe after the timeout fails, so the breaker opens again
+		time.Sleep(20 * time.Millisecond)
+		if _, err := breaker.GetAPIResponse(ctx, normalAccount, GameModeNormal); err == nil {
+			t.Fatalf("Expected probe failure, got success")
+		}
+
+		if state := breaker.Status().State; state != BreakerOpen.String() {
+			t.Errorf("Expected breaker to be %s after failed probe, got %s", BreakerOpen, state)
+		}
+
+		// The next probe succeeds, so the breaker closes
+		atomic.StoreInt32(&client.up, 1)
+		time.Sleep(20 * time.Millisecond)
+		if _, err := breaker.GetAPIResponse(ctx, normalAccount, GameModeNormal); err != nil {
+			t.Fatal(err)
+		}
+
+		status := breaker.Status()
+		if status.State != BreakerClosed.String() {
+			t.Errorf("Expected breaker to be %s after successful probe, got %s", BreakerClosed, status.State)
+		}
+
+		if status.ConsecutiveFailures != 0 {
+			t.Errorf("Expected failures to reset, got %d", status.ConsecutiveFailures)
+		}
+	})
+
+	t.Run("RequestsWaitForProbe", func(t *testing.T) {
+		client := newUnavailableHiscoreAPIClient()
+		breaker := NewCircuitBreakerHiscoreAPIClient(client, threshold, 10*time.Millisecond)
+
+		for i := 0; i < threshold; i++ {
+			breaker.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+		}
+
+		// Every request after the timeout arrives while the probe is running
+		time.Sleep(20 * time.Millisecond)
+		atomic.StoreInt32(&client.up, 1)
+		client.gate = make(chan struct{})
+
+		errs := make(chan error)
+		for i := 0; i < threshold; i++ {
+			go func() {
+				_, err := breaker.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+				errs <- err
+			}()
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		close(client.gate)
+
+		for i := 0; i < threshold; i++ {
+			if err := <-errs; err != nil {
+				t.Errorf("Expected every request to succeed after recovery, got %v", err)
+			}
+		}
+	})
+
+	t.Run("WaitIsCanceled", func(t *testing.T) {
+		client := newUnavailableHiscoreAPIClient()
+		breaker := NewCircuitBreakerHiscoreAPIClient(client, threshold, 10*time.Millisecond)
+
+		for i := 0; i < threshold; i++ {
+			breaker.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		atomic.StoreInt32(&client.up, 1)
+		client.gate = make(chan struct{})
+		defer close(client.gate)
+
+		go breaker.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+		time.Sleep(20 * time.Millisecond)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		if _, err := breaker.GetAPIResponse(canceled, normalAccount, GameModeNormal); err != context.Canceled {
+			t.Errorf("Expected %v while waiting for the probe, got %v", context.Canceled, err)
+		}
+	})
+}
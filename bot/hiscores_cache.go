@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachingHiscoreAPIClient Implementation of HiscoreAPIClient that caches the
+// responses of another HiscoreAPIClient by player and GameMode
+//
+// Responses are cached for TTL, and once the cache holds MaxEntries responses the
+// least recently used one is evicted. Concurrent requests for the same player and
+// GameMode share a single request to the wrapped client
+type CachingHiscoreAPIClient struct {
+	Client     HiscoreAPIClient
+	TTL        time.Duration
+	MaxEntries int
+
+	mutex    sync.Mutex
+	entries  map[hiscoreCacheKey]*list.Element
+	lru      *list.List
+	inFlight map[hiscoreCacheKey]*hiscoreCacheCall
+	stats    CacheStats
+}
+
+// CacheStats Counters describing how effective a CachingHiscoreAPIClient is
+type CacheStats struct {
+	// Hits Requests answered from the cache
+	Hits uint64 `json:"hits"`
+	// Misses Requests forwarded to the wrapped client
+	Misses uint64 `json:"misses"`
+	// Coalesced Requests that waited on an identical request already in flight
+	Coalesced uint64 `json:"coalesced"`
+	// Entries Number of responses currently cached
+	Entries int `json:"entries"`
+}
+
+// hiscoreCacheKey Key responses are cached under
+type hiscoreCacheKey struct {
+	player string
+	mode   GameMode
+}
+
+// hiscoreCacheEntry Cached response, kept as the value of an LRU list element
+type hiscoreCacheEntry struct {
+	key      hiscoreCacheKey
+	response string
+	err      error
+	expires  time.Time
+}
+
+// hiscoreCacheCall Request in flight to the wrapped client. done is closed once
+// response and err are set
+type hiscoreCacheCall struct {
+	done     chan struct{}
+	response string
+	err      error
+}
+
+// NewCachingHiscoreAPIClient Returns a CachingHiscoreAPIClient wrapping client
+func NewCachingHiscoreAPIClient(client HiscoreAPIClient, ttl time.Duration, maxEntries int) *CachingHiscoreAPIClient {
+	return &CachingHiscoreAPIClient{
+		Client:     client,
+		TTL:        ttl,
+		MaxEntries: maxEntries,
+		entries:    map[hiscoreCacheKey]*list.Element{},
+		lru:        list.New(),
+		inFlight:   map[hiscoreCacheKey]*hiscoreCacheCall{},
+	}
+}
+
+// GetAPIResponse Returns the cached response for the player and GameMode if there is
+// one, otherwise requests it from the wrapped client, or waits on an identical
+// request already in flight
+func (cache *CachingHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	key := hiscoreCacheKey{strings.ToLower(player), mode}
+
+	cache.mutex.Lock()
+
+	if element, ok := cache.entries[key]; ok {
+		entry := element.Value.(*hiscoreCacheEntry)
+
+		if time.Now().Before(entry.expires) {
+			cache.lru.MoveToFront(element)
+			cache.stats.Hits++
+			cache.mutex.Unlock()
+			return entry.response, entry.err
+		}
+
+		cache.removeElement(element)
+	}
+
+	if call, ok := cache.inFlight[key]; ok {
+		cache.stats.Coalesced++
+		cache.mutex.Unlock()
+		return cache.wait(ctx, call, player, mode)
+	}
+
+	call := &hiscoreCacheCall{done: make(chan struct{})}
+	cache.inFlight[key] = call
+	cache.stats.Misses++
+	cache.mutex.Unlock()
+
+	call.response, call.err = cache.Client.GetAPIResponse(ctx, player, mode)
+
+	cache.mutex.Lock()
+	delete(cache.inFlight, key)
+	if cacheableHiscoreError(call.err) {
+		cache.add(&hiscoreCacheEntry{key, call.response, call.err, time.Now().Add(cache.TTL)})
+	}
+	cache.mutex.Unlock()
+
+	close(call.done)
+	return call.response, call.err
+}
+
+// wait Waits on a request in flight for another caller. If that request was
+// canceled by its own caller while ctx is still live, the request is made again
+func (cache *CachingHiscoreAPIClient) wait(ctx context.Context, call *hiscoreCacheCall, player string, mode GameMode) (string, error) {
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	if (call.err == context.Canceled || call.err == context.DeadlineExceeded) && ctx.Err() == nil {
+		return cache.GetAPIResponse(ctx, player, mode)
+	}
+
+	return call.response, call.err
+}
+
+// ParseHiscores Parses the response with the wrapped client
+func (cache *CachingHiscoreAPIClient) ParseHiscores(response string) (Hiscores, error) {
+	return cache.Client.ParseHiscores(response)
+}
+
+// Unwrap Returns the wrapped client
+func (cache *CachingHiscoreAPIClient) Unwrap() HiscoreAPIClient {
+	return cache.Client
+}
+
+// Stats Returns the current cache counters
+func (cache *CachingHiscoreAPIClient) Stats() CacheStats {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	stats := cache.stats
+	stats.Entries = cache.lru.Len()
+	return stats
+}
+
+// add Adds the entry to the cache as the most recently used, evicting the least
+// recently used entries past MaxEntries. Must be called with the mutex held
+func (cache *CachingHiscoreAPIClient) add(entry *hiscoreCacheEntry) {
+	if element, ok := cache.entries[entry.key]; ok {
+		cache.removeElement(element)
+	}
+
+	cache.entries[entry.key] = cache.lru.PushFront(entry)
+
+	for cache.MaxEntries > 0 && cache.lru.Len() > cache.MaxEntries {
+		cache.removeElement(cache.lru.Back())
+	}
+}
+
+// removeElement Removes the entry held by element from the cache. Must be called
+// with the mutex held
+func (cache *CachingHiscoreAPIClient) removeElement(element *list.Element) {
+	cache.lru.Remove(element)
+	delete(cache.entries, element.Value.(*hiscoreCacheEntry).key)
+}
+
+// cacheableHiscoreError Returns true if a response with the given error can be
+// cached. Besides successes, knowing a player isn't in a GameMode is worth caching
+// since every GameMode is queried when detecting a player's GameMode
+func cacheableHiscoreError(err error) bool {
+	if err == nil {
+		return true
+	}
+
+	_, ok := err.(*HiscoreAPIError)
+	return ok
+}
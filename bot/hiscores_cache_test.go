@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gatedHiscoreAPIClient Holds every request until the gate is closed
+type gatedHiscoreAPIClient struct {
+	countingHiscoreAPIClient
+	gate chan struct{}
+}
+
+func (client *gatedHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	<-client.gate
+	return client.countingHiscoreAPIClient.GetAPIResponse(ctx, player, mode)
+}
+
+func TestCachingHiscoreAPIClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Hit", func(t *testing.T) {
+		client := &countingHiscoreAPIClient{HiscoreAPIClient: &mockHiscoreAPIClient{}}
+		cache := NewCachingHiscoreAPIClient(client, time.Minute, 10)
+
+		first, err := cache.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		second, err := cache.GetAPIResponse(ctx, "NORMAL", GameModeNormal)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if first != second {
+			t.Errorf("Cached response differs from original response")
+		}
+
+		if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+			t.Errorf("Expected 1 upstream request, got %d", calls)
+		}
+
+		if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+			t.Errorf("Unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		client := &countingHiscoreAPIClient{HiscoreAPIClient: &mockHiscoreAPIClient{}}
+		cache := NewCachingHiscoreAPIClient(client, time.Minute, 10)
+
+		for i := 0; i < 2; i++ {
+			if _, err := cache.GetAPIResponse(ctx, normalAccount, GameModeIronman); err == nil {
+				t.Fatalf("Expected lookup failure, got success")
+			}
+		}
+
+		if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+			t.Errorf("Expected 1 upstream request, got %d", calls)
+		}
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		client := &countingHiscoreAPIClient{HiscoreAPIClient: &mockHiscoreAPIClient{}}
+		cache := NewCachingHiscoreAPIClient(client, time.Millisecond, 10)
+
+		cache.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+		time.Sleep(5 * time.Millisecond)
+		cache.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+
+		if calls := atomic.LoadInt32(&client.calls); calls != 2 {
+			t.Errorf("Expected 2 upstream requests, got %d", calls)
+		}
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+		client := &countingHiscoreAPIClient{HiscoreAPIClient: &mockHiscoreAPIClient{}}
+		cache := NewCachingHiscoreAPIClient(client, time.Minute, 2)
+
+		cache.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+		cache.GetAPIResponse(ctx, ironmanAccount, GameModeNormal)
+		// Normal becomes the most recently used, so Ironman is evicted next
+		cache.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+		cache.GetAPIResponse(ctx, hardcoreAccount, GameModeNormal)
+
+		atomic.StoreInt32(&client.calls, 0)
+		cache.GetAPIResponse(ctx, normalAccount, GameModeNormal)
+		if calls := atomic.LoadInt32(&client.calls); calls != 0 {
+			t.Errorf("Recently used entry was evicted")
+		}
+
+		cache.GetAPIResponse(ctx, ironmanAccount, GameModeNormal)
+		if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+			t.Errorf("Least recently used entry was not evicted")
+		}
+
+		if entries := cache.Stats().Entries; entries != 2 {
+			t.Errorf("Expected 2 entries, got %d", entries)
+		}
+	})
+
+	t.Run("Coalescing", func(t *testing.T) {
+		client := &gatedHiscoreAPIClient{
+			countingHiscoreAPIClient: countingHiscoreAPIClient{HiscoreAPIClient: &mockHiscoreAPIClient{}},
+			gate:                     make(chan struct{}),
+		}
+		cache := NewCachingHiscoreAPIClient(client, time.Minute, 10)
+
+		lookups := 20
+		var wg sync.WaitGroup
+		wg.Add(lookups)
+		for i := 0; i < lookups; i++ {
+			go func() {
+				defer wg.Done()
+				if _, err := cache.GetAPIResponse(ctx, ironmanAccount, GameModeIronman); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+
+		// Let every lookup reach the cache before the upstream request completes
+		for cache.Stats().Coalesced < uint64(lookups-1) {
+			time.Sleep(time.Millisecond)
+		}
+		close(client.gate)
+		wg.Wait()
+
+		if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+			t.Errorf("Expected 1 upstream request, got %d", calls)
+		}
+	})
+}
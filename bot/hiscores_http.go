@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HiscoreHTTPClient Sends requests to the Hiscore API, retrying requests that fail
+// for reasons that are likely to be temporary: network errors, server errors (5xx)
+// and rate limiting (429)
+//
+// Retries are spaced by an exponential backoff starting at MinBackoff and capped at
+// MaxBackoff, with jitter so that concurrent lookups don't retry in lockstep
+type HiscoreHTTPClient struct {
+	// Client HTTP client used to send requests, http.DefaultClient if nil
+	Client *http.Client
+
+	// MaxRetries Number of times a failed request is retried before giving up
+	MaxRetries int
+
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// HiscoresUnavailableError Returned when the Hiscore API can't serve a request,
+// as opposed to HiscoreAPIError where it confirms the player doesn't exist
+type HiscoresUnavailableError struct {
+	Mode GameMode
+
+	// StatusCode Status of the last response, 0 if there was no response
+	StatusCode int
+	// Err Error sending the last request, if there was one
+	Err error
+}
+
+func (e *HiscoresUnavailableError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s hiscores are unavailable: %s", e.Mode.Name, e.Err)
+	}
+
+	return fmt.Sprintf("%s hiscores are unavailable: status %d", e.Mode.Name, e.StatusCode)
+}
+
+// NewHiscoreHTTPClient Returns a HiscoreHTTPClient with a request timeout and
+// retries suited to the OSRS Hiscore API
+func NewHiscoreHTTPClient() HiscoreHTTPClient {
+	return HiscoreHTTPClient{
+		Client:     &http.Client{Timeout: 5 * time.Second},
+		MaxRetries: 2,
+		MinBackoff: 250 * time.Millisecond,
+		MaxBackoff: 2 * time.Second,
+	}
+}
+
+// Get Sends a GET request to a Hiscore API URL for the player and GameMode, and
+// returns the response body as a string
+//
+// Returns HiscoreAPIError if the player doesn't exist in the GameMode, and
+// HiscoresUnavailableError if the Hiscore API couldn't answer after all retries
+func (c *HiscoreHTTPClient) Get(ctx context.Context, url, player string, mode GameMode) (string, error) {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	for attempt := 0; ; attempt++ {
+		body, retryAfter, err := c.get(ctx, client, url, player, mode)
+
+		if !retryableHiscoreError(err) || attempt >= c.MaxRetries {
+			return body, err
+		}
+
+		// Honor the rate limit as far as MaxBackoff allows
+		backoff := c.backoff(attempt)
+		if retryAfter > backoff {
+			backoff = retryAfter
+			if backoff > c.MaxBackoff {
+				backoff = c.MaxBackoff
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// get Sends a single request, returning how long the Hiscore API asked to wait
+// before retrying if it rate limited the request
+func (c *HiscoreHTTPClient) get(ctx context.Context, client *http.Client, url, player string, mode GameMode) (string, time.Duration, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+
+	if err != nil {
+		// Canceled lookups aren't the Hiscore API's fault
+		if ctx.Err() != nil {
+			return "", 0, ctx.Err()
+		}
+
+		return "", 0, &HiscoresUnavailableError{Mode: mode, Err: err}
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		bodyBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return "", 0, &HiscoresUnavailableError{Mode: mode, Err: err}
+		}
+
+		return string(bodyBytes), 0, nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest:
+		// The Hiscore API 404s when the player does not exist in the given mode, and
+		// 400s when the player name couldn't belong to any player
+		return "", 0, &HiscoreAPIError{player, mode}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return "", time.Duration(retryAfter) * time.Second, &HiscoresUnavailableError{Mode: mode, StatusCode: resp.StatusCode}
+	default:
+		return "", 0, &HiscoresUnavailableError{Mode: mode, StatusCode: resp.StatusCode}
+	}
+}
+
+// retryableHiscoreError Returns true if a request failing with err is worth retrying.
+// Any status besides 429 and 5xx is unexpected and won't change by retrying
+func retryableHiscoreError(err error) bool {
+	unavailable, ok := err.(*HiscoresUnavailableError)
+	if !ok {
+		return false
+	}
+
+	return unavailable.StatusCode == 0 ||
+		unavailable.StatusCode == http.StatusTooManyRequests ||
+		unavailable.StatusCode >= 500
+}
+
+// backoff Returns the jittered delay before the retry following the given attempt
+func (c *HiscoreHTTPClient) backoff(attempt int) time.Duration {
+	backoff := c.MinBackoff << uint(attempt)
+	if backoff > c.MaxBackoff || backoff <= 0 {
+		backoff = c.MaxBackoff
+	}
+
+	// Wait anywhere between half and all of the backoff
+	half := int64(backoff / 2)
+	if half <= 0 {
+		return backoff
+	}
+
+	return time.Duration(half + rand.Int63n(half+1))
+}
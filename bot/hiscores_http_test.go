@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newStatusServer Responds to each request with the next status in statuses,
+// repeating the last one once they run out
+func newStatusServer(statuses []int, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(atomic.AddInt32(calls, 1)) - 1
+		if call >= len(statuses) {
+			call = len(statuses) - 1
+		}
+
+		w.WriteHeader(statuses[call])
+		w.Write([]byte("ok"))
+	}))
+}
+
+func newTestHiscoreHTTPClient() HiscoreHTTPClient {
+	return HiscoreHTTPClient{
+		Client:     &http.Client{Timeout: time.Second},
+		MaxRetries: 2,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+	}
+}
+
+func TestHiscoreHTTPClientGet(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("RecoversAfterRetry", func(t *testing.T) {
+		var calls int32
+		server := newStatusServer([]int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, &calls)
+		defer server.Close()
+
+		client := newTestHiscoreHTTPClient()
+		body, err := client.Get(ctx, server.URL, normalAccount, GameModeNormal)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if body != "ok" {
+			t.Errorf("Expected body ok, got %s", body)
+		}
+
+		if calls != 3 {
+			t.Errorf("Expected 3 requests, got %d", calls)
+		}
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		var calls int32
+		server := newStatusServer([]int{http.StatusBadGateway}, &calls)
+		defer server.Close()
+
+		client := newTestHiscoreHTTPClient()
+		_, err := client.Get(ctx, server.URL, normalAccount, GameModeNormal)
+
+		if unavailable, ok := err.(*HiscoresUnavailableError); !ok {
+			t.Errorf("Expected HiscoresUnavailableError, got %v", err)
+		} else if unavailable.StatusCode != http.StatusBadGateway {
+			t.Errorf("Expected status %d, got %d", http.StatusBadGateway, unavailable.StatusCode)
+		}
+
+		if calls != 3 {
+			t.Errorf("Expected 3 requests, got %d", calls)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		var calls int32
+		server := newStatusServer([]int{http.StatusNotFound}, &calls)
+		defer server.Close()
+
+		client := newTestHiscoreHTTPClient()
+		_, err := client.Get(ctx, server.URL, notAnAccount, GameModeNormal)
+
+		if _, ok := err.(*HiscoreAPIError); !ok {
+			t.Errorf("Expected HiscoreAPIError, got %v", err)
+		}
+
+		if calls != 1 {
+			t.Errorf("Expected no retries, got %d requests", calls)
+		}
+	})
+
+	t.Run("UnexpectedStatus", func(t *testing.T) {
+		var calls int32
+		server := newStatusServer([]int{http.StatusForbidden}, &calls)
+		defer server.Close()
+
+		client := newTestHiscoreHTTPClient()
+		_, err := client.Get(ctx, server.URL, normalAccount, GameModeNormal)
+
+		if _, ok := err.(*HiscoresUnavailableError); !ok {
+			t.Errorf("Expected HiscoresUnavailableError, got %v", err)
+		}
+
+		if calls != 1 {
+			t.Errorf("Expected no retries, got %d requests", calls)
+		}
+	})
+
+	t.Run("NetworkError", func(t *testing.T) {
+		var calls int32
+		server := newStatusServer([]int{http.StatusOK}, &calls)
+		url := server.URL
+		server.Close()
+
+		client := newTestHiscoreHTTPClient()
+		_, err := client.Get(ctx, url, normalAccount, GameModeNormal)
+
+		if unavailable, ok := err.(*HiscoresUnavailableError); !ok {
+			t.Errorf("Expected HiscoresUnavailableError, got %v", err)
+		} else if unavailable.Err == nil {
+			t.Errorf("Expected the network error to be kept")
+		}
+	})
+}
+
+func TestFormatLookupFailureOutput(t *testing.T) {
+	user := "TestUser"
+
+	testCases := map[string]struct {
+		err      error
+		expected string
+	}{
+		"NotFound": {
+			&HiscoreAPIError{notAnAccount, GameModeNormal},
+			"@TestUser Could not find player " + notAnAccount,
+		},
+		"Unavailable": {
+			&HiscoresUnavailableError{Mode: GameModeNormal, StatusCode: http.StatusServiceUnavailable},
+			"@TestUser The OSRS hiscores are unavailable right now, try again later",
+		},
+		"Timeout": {
+			context.DeadlineExceeded,
+			"@TestUser The OSRS hiscores are unavailable right now, try again later",
+		},
+	}
+
+	for name, tc := range testCases {
+		if actual := FormatLookupFailureOutput(user, notAnAccount, tc.err); actual != tc.expected {
+			t.Errorf("%s: said %s, but expected to say %s", name, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// OSRSJSONHiscoreAPIClient Implementation of HiscoreAPIClient that queries the JSON
+// variant of the OSRS Hiscore API. Every skill and activity in a JSON response is
+// named, so unlike the CSV response it isn't affected by rows being added or
+// reordered
+type OSRSJSONHiscoreAPIClient struct {
+	HTTP HiscoreHTTPClient
+
+	// BaseURL Host serving the Hiscore API, defaults to HiscoreBaseURL if empty
+	BaseURL string
+}
+
+// jsonHiscores Schema of the JSON Hiscore API response
+type jsonHiscores struct {
+	Skills []struct {
+		Name  string `json:"name"`
+		Rank  int    `json:"rank"`
+		Level int    `json:"level"`
+		XP    int    `json:"xp"`
+	} `json:"skills"`
+	Activities []struct {
+		Name  string `json:"name"`
+		Rank  int    `json:"rank"`
+		Score int    `json:"score"`
+	} `json:"activities"`
+}
+
+// layoutEntry Position of a single named entry within hiscoreLayout
+type layoutEntry struct {
+	group hiscoreGroup
+	index int
+	name  string
+}
+
+var (
+	// Skill names used by the JSON Hiscore API that differ from skillNames
+	jsonSkillNames map[string]string = map[string]string{
+		"Defence": "Defense",
+	}
+
+	// Entries of hiscoreLayout keyed by the name the JSON Hiscore API uses for them
+	jsonLayoutEntries map[string]layoutEntry = newJSONLayoutEntries(hiscoreLayout)
+)
+
+// jsonHiscoreName Returns the name the JSON Hiscore API uses for the named entry
+// of the given group
+func jsonHiscoreName(group hiscoreGroup, name string) string {
+	switch group {
+	case groupSkill:
+		for jsonName, skillName := range jsonSkillNames {
+			if skillName == name {
+				return jsonName
+			}
+		}
+	case groupBountyHunter:
+		return "Bounty Hunter - " + name
+	case groupLMS:
+		return "LMS - Rank"
+	case groupClue:
+		if name == "Overall" {
+			return "Clue Scrolls (all)"
+		}
+		return "Clue Scrolls (" + strings.ToLower(name) + ")"
+	}
+
+	return name
+}
+
+// jsonLayoutKey Returns the key of the named entry of the given group within
+// jsonLayoutEntries. Skills are kept apart from activities, as the API lists them
+// separately
+func jsonLayoutKey(group hiscoreGroup, name string) string {
+	if group == groupSkill {
+		return "skill:" + jsonHiscoreName(group, name)
+	}
+
+	return jsonHiscoreName(group, name)
+}
+
+// newJSONLayoutEntries Indexes every entry of the layout by its JSON Hiscore API name
+func newJSONLayoutEntries(layout []hiscoreSection) map[string]layoutEntry {
+	entries := map[string]layoutEntry{}
+
+	for _, section := range layout {
+		for i, name := range section.names {
+			entries[jsonLayoutKey(section.group, name)] = layoutEntry{section.group, i, name}
+		}
+	}
+
+	return entries
+}
+
+// GetAPIResponse Sends a GET request to the JSON OSRS Hiscore API according to the
+// player and GameMode, and returns the response as a JSON string
+func (osrsAPI *OSRSJSONHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	baseURL := osrsAPI.BaseURL
+	if baseURL == "" {
+		baseURL = HiscoreBaseURL
+	}
+
+	return osrsAPI.HTTP.Get(ctx, formatHiscoreURL(baseURL, "index_lite.json", player, mode), player, mode)
+}
+
+// ParseHiscores Parses a JSON response from the OSRS Hiscore API into Hiscores.
+// Skills and activities are matched by name, and any the bot doesn't know about
+// are ignored. Returns HiscoreLayoutError if any the bot knows about are missing
+func (osrsAPI *OSRSJSONHiscoreAPIClient) ParseHiscores(response string) (Hiscores, error) {
+	parsed := jsonHiscores{}
+	if err := json.Unmarshal([]byte(response), &parsed); err != nil {
+		return Hiscores{}, err
+	}
+
+	hiscores := newHiscores()
+	seen := map[string]bool{}
+
+	for _, skill := range parsed.Skills {
+		if entry, ok := jsonLayoutEntries["skill:"+skill.Name]; ok {
+			seen["skill:"+skill.Name] = true
+			hiscores.skills[entry.index] = SkillHiscore{
+				Rank:  skill.Rank,
+				Level: skill.Level,
+				Exp:   skill.XP,
+			}
+		}
+	}
+
+	for _, activity := range parsed.Activities {
+		entry, ok := jsonLayoutEntries[activity.Name]
+		if !ok {
+			continue
+		}
+		seen[activity.Name] = true
+
+		// Unranked activities are left as the zero value, same as in the CSV response
+		if activity.Rank == -1 {
+			continue
+		}
+
+		hiscores.setMinigameHiscore(entry.group, entry.index, entry.name, MinigameHiscore{
+			Rank:  activity.Rank,
+			Score: activity.Score,
+		})
+	}
+
+	// A renamed entry would otherwise be left unranked without any error
+	for _, section := range hiscoreLayout {
+		for _, name := range section.names {
+			if !seen[jsonLayoutKey(section.group, name)] {
+				return Hiscores{}, &HiscoreLayoutError{Missing: name}
+			}
+		}
+	}
+
+	return hiscores, nil
+}
+
+// NewOSRSJSONHiscoreAPI Returns a Hiscore API with the JSON OSRS API client implementation
+func NewOSRSJSONHiscoreAPI() *HiscoreAPI {
+	return &HiscoreAPI{
+		Client:  &OSRSJSONHiscoreAPIClient{HTTP: NewHiscoreHTTPClient()},
+		Timeout: DefaultLookupTimeout,
+		ModeTTL: DefaultModeTTL,
+	}
+}
@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newMockJSONHiscoreServer Serves the recorded JSON fixture for normalAccount in
+// the Normal GameMode, and 404s for everything else like the real API does
+func newMockJSONHiscoreServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expectedPath := "/m=hiscore_oldschool" + GameModeNormal.urlComponent + "/index_lite.json"
+
+		if r.URL.Path != expectedPath || r.URL.Query().Get("player") != normalAccount {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "testdata/hiscores.json")
+	}))
+}
+
+func TestNewOSRSJSONHiscoreAPI(t *testing.T) {
+	api := NewOSRSJSONHiscoreAPI()
+
+	if _, ok := api.Client.(*OSRSJSONHiscoreAPIClient); !ok {
+		t.Fatalf("New OSRS JSON Hiscore API does not use OSRS JSON Hiscore API Client")
+	}
+}
+
+func TestJSONHiscoresLookup(t *testing.T) {
+	server := newMockJSONHiscoreServer()
+	defer server.Close()
+
+	api := &HiscoreAPI{
+		Client: &OSRSJSONHiscoreAPIClient{BaseURL: server.URL},
+	}
+
+	t.Run("ValidPlayer", func(t *testing.T) {
+		actual, err := api.LookupHiscoresByGameMode(normalAccount, GameModeNormal)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The fixture holds the same scores as the mock CSV response, with rows
+		// added and reordered around them
+		expected, err := NewMockHiscoreAPI().LookupHiscoresByGameMode(normalAccount, GameModeNormal)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("JSON hiscores %+v do not match CSV hiscores %+v", actual, expected)
+		}
+	})
+
+	t.Run("InvalidPlayer", func(t *testing.T) {
+		_, err := api.LookupHiscoresByGameMode(notAnAccount, GameModeNormal)
+
+		if _, ok := err.(*HiscoreAPIError); !ok {
+			t.Errorf("Expected HiscoreAPIError, got %v", err)
+		}
+	})
+
+	t.Run("IncompatibleMode", func(t *testing.T) {
+		_, err := api.LookupHiscoresByGameMode(normalAccount, GameModeIronman)
+
+		if _, ok := err.(*HiscoreAPIError); !ok {
+			t.Errorf("Expected HiscoreAPIError, got %v", err)
+		}
+	})
+}
+
+func TestJSONParseHiscores(t *testing.T) {
+	client := &OSRSJSONHiscoreAPIClient{}
+
+	t.Run("Malformed", func(t *testing.T) {
+		if _, err := client.ParseHiscores("<html>Maintenance</html>"); err == nil {
+			t.Errorf("Expected parse failure, got success")
+		}
+	})
+
+	t.Run("UnknownEntries", func(t *testing.T) {
+		fixture := readJSONFixture(t)
+		fixture["skills"] = append(fixture["skills"], map[string]interface{}{
+			"id": 99, "name": "Sailing", "rank": 1, "level": 99, "xp": 13034431,
+		})
+		fixture["activities"] = append(fixture["activities"], map[string]interface{}{
+			"id": 99, "name": "Colosseum Glory", "rank": 1, "score": 25000,
+		})
+
+		hiscores, err := client.ParseHiscores(marshalJSONFixture(t, fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := client.ParseHiscores(marshalJSONFixture(t, readJSONFixture(t)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(hiscores, expected) {
+			t.Errorf("Expected unknown entries to be ignored, got %+v", hiscores)
+		}
+	})
+
+	t.Run("MissingEntry", func(t *testing.T) {
+		fixture := readJSONFixture(t)
+		activities := fixture["activities"][:0]
+		for _, activity := range fixture["activities"] {
+			if activity["name"] != "Zulrah" {
+				activities = append(activities, activity)
+			}
+		}
+		fixture["activities"] = activities
+
+		_, err := client.ParseHiscores(marshalJSONFixture(t, fixture))
+
+		if layoutErr, ok := err.(*HiscoreLayoutError); !ok || layoutErr.Missing != "Zulrah" {
+			t.Errorf("Expected HiscoreLayoutError for Zulrah, got %v", err)
+		}
+	})
+}
+
+// readJSONFixture Reads the recorded JSON fixture as skill and activity entries
+func readJSONFixture(t *testing.T) map[string][]map[string]interface{} {
+	contents, err := ioutil.ReadFile("testdata/hiscores.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixture := map[string][]map[string]interface{}{}
+	if err := json.Unmarshal(contents, &fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	return fixture
+}
+
+// marshalJSONFixture Returns the fixture as a JSON Hiscore API response
+func marshalJSONFixture(t *testing.T, fixture map[string][]map[string]interface{}) string {
+	contents, err := json.Marshal(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(contents)
+}
@@ -1,14 +1,18 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type mockHiscoreAPIClient struct{}
 
-func (mock mockHiscoreAPIClient) GetAPIResponse(player string, mode GameMode) (string, error) {
+func (mock mockHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
 	mockScores := []string{
 		"1140740,922,26362111", // Skills start here
 		"1600556,50,102080",
@@ -44,6 +48,49 @@ func (mock mockHiscoreAPIClient) GetAPIResponse(player string, mode GameMode) (s
 		"-1,-1",
 		"-1,-1",
 		"-1,-1",
+		"-1,-1", // Bosses start here
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"-1,-1",
+		"9876,150", // Vorkath
+		"-1,-1",
+		"-1,-1",
+		"4321,512", // Zulrah
 	}
 
 	if player == fallenHardcoreAccount && mode != GameModeHardcoreIronman {
@@ -58,7 +105,9 @@ func (mock mockHiscoreAPIClient) GetAPIResponse(player string, mode GameMode) (s
 	case GameModeHardcoreIronman:
 		isValid = player == hardcoreAccount || player == fallenHardcoreAccount
 	case GameModeIronman:
-		isValid = player != notAnAccount && player != normalAccount
+		isValid = player != notAnAccount && player != normalAccount && player != groupIronmanAccount
+	case GameModeGroupIronman:
+		isValid = player == groupIronmanAccount
 	case GameModeNormal:
 		isValid = player != notAnAccount
 	}
@@ -70,14 +119,72 @@ func (mock mockHiscoreAPIClient) GetAPIResponse(player string, mode GameMode) (s
 	return "", &HiscoreAPIError{player, mode}
 }
 
+func (mock mockHiscoreAPIClient) ParseHiscores(response string) (Hiscores, error) {
+	return parseCSVHiscores(response)
+}
+
 const (
 	normalAccount         string = "Normal"
 	ironmanAccount        string = "Ironman"
 	hardcoreAccount       string = "HCIM"
 	fallenHardcoreAccount string = "Fallen HCIM"
+	groupIronmanAccount   string = "GIM"
 	notAnAccount          string = "Invalid Acc"
 )
 
+// countingHiscoreAPIClient Wraps a HiscoreAPIClient, counting requests made through it
+type countingHiscoreAPIClient struct {
+	HiscoreAPIClient
+	calls int32
+}
+
+func (client *countingHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	atomic.AddInt32(&client.calls, 1)
+	return client.HiscoreAPIClient.GetAPIResponse(ctx, player, mode)
+}
+
+// blockingHiscoreAPIClient Never responds until the request is canceled
+type blockingHiscoreAPIClient struct {
+	mockHiscoreAPIClient
+}
+
+func (client blockingHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+// gameModeHiscoreAPIClient Answers for every player with the hiscores stubbed for
+// each GameMode, which can be swapped between lookups
+type gameModeHiscoreAPIClient struct {
+	mutex    sync.Mutex
+	hiscores map[string]Hiscores
+}
+
+func (client *gameModeHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if _, ok := client.hiscores[mode.urlComponent]; !ok {
+		return "", &HiscoreAPIError{player, mode}
+	}
+
+	return mode.urlComponent, nil
+}
+
+func (client *gameModeHiscoreAPIClient) ParseHiscores(response string) (Hiscores, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	return client.hiscores[response], nil
+}
+
+func (client *gameModeHiscoreAPIClient) set(mode GameMode, hiscores Hiscores) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	client.hiscores[mode.urlComponent] = hiscores
+}
+
 func NewMockHiscoreAPI() *HiscoreAPI {
 	return &HiscoreAPI{
 		Client: &mockHiscoreAPIClient{},
@@ -119,13 +226,23 @@ func TestFormatHiscoreAPIURL(t *testing.T) {
 			),
 		},
 		urlFormatTestCase{
-			player: notAnAccount,
-			mode:   GameModeHardcoreIronman,
+			player: groupIronmanAccount,
+			mode:   GameModeGroupIronman,
 			expected: fmt.Sprintf(
-				"https://secure.runescape.com/m=hiscore_oldschool_hardcore_ironman/index_lite.ws?player=%s",
-				notAnAccount,
+				"https://secure.runescape.com/m=hiscore_oldschool_group_ironman/index_lite.ws?player=%s",
+				groupIronmanAccount,
 			),
 		},
+		urlFormatTestCase{
+			player:   notAnAccount,
+			mode:     GameModeHardcoreIronman,
+			expected: "https://secure.runescape.com/m=hiscore_oldschool_hardcore_ironman/index_lite.ws?player=Invalid+Acc",
+		},
+		urlFormatTestCase{
+			player:   "Tom&Jerry=1",
+			mode:     GameModeNormal,
+			expected: "https://secure.runescape.com/m=hiscore_oldschool/index_lite.ws?player=Tom%26Jerry%3D1",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -165,6 +282,8 @@ func TestHiscoresLookup(t *testing.T) {
 			"IncompatibleMode": []hiscoreTestCase{
 				{hardcoreAccount, GameModeUltimateIronman, false},
 				{normalAccount, GameModeIronman, false},
+				{groupIronmanAccount, GameModeIronman, false},
+				{ironmanAccount, GameModeGroupIronman, false},
 			},
 		}
 
@@ -205,7 +324,8 @@ func TestHiscoresLookup(t *testing.T) {
 			normalAccount:         GameModeNormal,
 			ironmanAccount:        GameModeIronman,
 			hardcoreAccount:       GameModeHardcoreIronman,
-			fallenHardcoreAccount: GameModeIronman,
+			fallenHardcoreAccount: GameModeFormerHardcoreIronman,
+			groupIronmanAccount:   GameModeGroupIronman,
 		}
 
 		for player, expectedMode := range players {
@@ -224,6 +344,96 @@ func TestHiscoresLookup(t *testing.T) {
 	})
 }
 
+func TestHiscoresLookupGameModeMemo(t *testing.T) {
+	client := &countingHiscoreAPIClient{HiscoreAPIClient: &mockHiscoreAPIClient{}}
+	api := &HiscoreAPI{
+		Client:  client,
+		ModeTTL: time.Minute,
+	}
+
+	if _, mode, err := api.LookupHiscores(hardcoreAccount); err != nil {
+		t.Fatal(err)
+	} else if mode != GameModeHardcoreIronman {
+		t.Fatalf("Expected %s, got %s", GameModeHardcoreIronman.Name, mode.Name)
+	}
+
+	atomic.StoreInt32(&client.calls, 0)
+	_, mode, err := api.LookupHiscores(hardcoreAccount)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mode != GameModeHardcoreIronman {
+		t.Errorf("Expected %s, got %s", GameModeHardcoreIronman.Name, mode.Name)
+	}
+
+	if calls := atomic.LoadInt32(&client.calls); calls != 2 {
+		t.Errorf("Expected 2 requests for a remembered game mode and its parent, got %d", calls)
+	}
+}
+
+func TestHiscoresLookupGameModeMemoLeft(t *testing.T) {
+	hiscores := newMilestoneHiscores(50)
+	client := &gameModeHiscoreAPIClient{hiscores: map[string]Hiscores{
+		GameModeNormal.urlComponent:          hiscores,
+		GameModeIronman.urlComponent:         hiscores,
+		GameModeHardcoreIronman.urlComponent: hiscores,
+	}}
+	api := &HiscoreAPI{
+		Client:  client,
+		ModeTTL: time.Minute,
+	}
+
+	if _, mode, err := api.LookupHiscores(hardcoreAccount); err != nil {
+		t.Fatal(err)
+	} else if mode != GameModeHardcoreIronman {
+		t.Fatalf("Expected %s, got %s", GameModeHardcoreIronman.Name, mode.Name)
+	}
+
+	// The hardcore scores freeze once the player dies, while they keep training
+	trained := newMilestoneHiscores(50)
+	trained.skills[SkillAgility].Exp++
+	client.set(GameModeNormal, trained)
+	client.set(GameModeIronman, trained)
+
+	actual, mode, err := api.LookupHiscores(hardcoreAccount)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mode != GameModeFormerHardcoreIronman {
+		t.Errorf("Expected %s, got %s", GameModeFormerHardcoreIronman.Name, mode.Name)
+	}
+
+	if !SameScores(actual, trained) {
+		t.Errorf("Expected the trained scores, got %+v", actual)
+	}
+}
+
+func TestHiscoresLookupTimeout(t *testing.T) {
+	api := &HiscoreAPI{
+		Client:  blockingHiscoreAPIClient{},
+		Timeout: 50 * time.Millisecond,
+	}
+
+	done := make(chan error)
+	go func() {
+		_, _, err := api.LookupHiscores(normalAccount)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != context.DeadlineExceeded {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("Lookup did not respect its deadline")
+	}
+}
+
 func TestGetSkillHiscoreFromName(t *testing.T) {
 	player := normalAccount
 	mode := GameModeNormal
@@ -239,7 +449,7 @@ func TestGetSkillHiscoreFromName(t *testing.T) {
 
 		name := "Ranged"
 		expected := "Ranged"
-		skillName, _, err := hiscores.GetSkillHiscoreFromName(name)
+		skillName, _, err := hiscores.GetSkillHiscoreFromName(name, nil)
 
 		if err != nil {
 			t.Fatal(err)
@@ -254,7 +464,23 @@ func TestGetSkillHiscoreFromName(t *testing.T) {
 
 		name := "wc"
 		expected := "Woodcutting"
-		skillName, _, err := hiscores.GetSkillHiscoreFromName(name)
+		skillName, _, err := hiscores.GetSkillHiscoreFromName(name, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if skillName != expected {
+			t.Errorf("Incorrect skill retrieved: expected %s, got %s", expected, skillName)
+		}
+	})
+	t.Run("ChannelAlias", func(t *testing.T) {
+		t.Parallel()
+
+		name := "rc"
+		aliases := map[string]string{"rc": "Ranged"}
+		expected := "Ranged"
+		skillName, _, err := hiscores.GetSkillHiscoreFromName(name, aliases)
 
 		if err != nil {
 			t.Fatal(err)
@@ -268,10 +494,195 @@ func TestGetSkillHiscoreFromName(t *testing.T) {
 		t.Parallel()
 
 		name := "Sailing"
-		_, _, err := hiscores.GetSkillHiscoreFromName(name)
+		_, _, err := hiscores.GetSkillHiscoreFromName(name, nil)
 
 		if err == nil {
 			t.Errorf("Skill hiscore lookup succeeded with invalid skill")
 		}
 	})
 }
+
+func TestGetBossHiscoreFromName(t *testing.T) {
+	player := normalAccount
+	mode := GameModeNormal
+	mockAPI := NewMockHiscoreAPI()
+	hiscores, err := mockAPI.LookupHiscoresByGameMode(player, mode)
+
+	if err != nil {
+		t.Errorf("Hiscore lookup failed for valid player")
+	}
+
+	t.Run("ExactName", func(t *testing.T) {
+		t.Parallel()
+
+		name := "vorkath"
+		expected := "Vorkath"
+		bossName, boss, err := hiscores.GetBossHiscoreFromName(name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bossName != expected {
+			t.Errorf("Incorrect boss retrieved: expected %s, got %s", expected, bossName)
+		}
+
+		if boss.Score != 150 {
+			t.Errorf("Incorrect kill count: expected 150, got %d", boss.Score)
+		}
+	})
+	t.Run("Alias", func(t *testing.T) {
+		t.Parallel()
+
+		name := "zul"
+		expected := "Zulrah"
+		bossName, _, err := hiscores.GetBossHiscoreFromName(name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bossName != expected {
+			t.Errorf("Incorrect boss retrieved: expected %s, got %s", expected, bossName)
+		}
+	})
+	t.Run("Unranked", func(t *testing.T) {
+		t.Parallel()
+
+		name := "cox"
+		_, _, err := hiscores.GetBossHiscoreFromName(name)
+
+		if _, ok := err.(*UnrankedError); !ok {
+			t.Errorf("Expected UnrankedError, got %v", err)
+		}
+	})
+	t.Run("WrongName", func(t *testing.T) {
+		t.Parallel()
+
+		name := "Nex"
+		_, _, err := hiscores.GetBossHiscoreFromName(name)
+
+		if err == nil {
+			t.Errorf("Boss hiscore lookup succeeded with invalid boss")
+		}
+	})
+}
+
+func TestGetMinigameHiscores(t *testing.T) {
+	hiscores, err := NewMockHiscoreAPI().LookupHiscoresByGameMode(normalAccount, GameModeNormal)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ClueAlias", func(t *testing.T) {
+		name, _, err := hiscores.GetClueHiscoreFromName("ELITE")
+
+		if name != "Elite" {
+			t.Errorf("Incorrect clue tier retrieved: expected Elite, got %s", name)
+		}
+
+		if _, ok := err.(*UnrankedError); !ok {
+			t.Errorf("Expected UnrankedError, got %v", err)
+		}
+	})
+
+	t.Run("WrongClueTier", func(t *testing.T) {
+		if _, _, err := hiscores.GetClueHiscoreFromName("grandmaster"); err == nil {
+			t.Errorf("Clue hiscore lookup succeeded with invalid tier")
+		}
+	})
+
+	t.Run("BountyHunter", func(t *testing.T) {
+		name, bountyHunter, err := hiscores.GetBountyHunterHiscoreFromName("rogues")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if name != "Rogue" || bountyHunter.Score != 36 {
+			t.Errorf("Incorrect Bounty Hunter score retrieved: got %s %+v", name, bountyHunter)
+		}
+	})
+
+	t.Run("LastManStanding", func(t *testing.T) {
+		lms, err := hiscores.LastManStanding()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if lms.Rank != 3308 || lms.Score != 992 {
+			t.Errorf("Incorrect Last Man Standing score retrieved: got %+v", lms)
+		}
+	})
+
+	t.Run("UnrankedLastManStanding", func(t *testing.T) {
+		if _, err := newHiscores().LastManStanding(); err == nil {
+			t.Errorf("Expected UnrankedError, got success")
+		}
+	})
+}
+
+func TestParseCSVHiscores(t *testing.T) {
+	validCSV, _ := mockHiscoreAPIClient{}.GetAPIResponse(context.Background(), normalAccount, GameModeNormal)
+	rows := strings.Fields(validCSV)
+
+	t.Run("ValidLayout", func(t *testing.T) {
+		hiscores, err := parseCSVHiscores(validCSV)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hiscores.lms.Score != 992 {
+			t.Errorf("Expected LMS score 992, got %d", hiscores.lms.Score)
+		}
+
+		if hiscores.bhRogue.Score != 36 {
+			t.Errorf("Expected Bounty Hunter rogue score 36, got %d", hiscores.bhRogue.Score)
+		}
+	})
+
+	t.Run("MissingRows", func(t *testing.T) {
+		_, err := parseCSVHiscores(strings.Join(rows[:30], " "))
+
+		if layoutErr, ok := err.(*HiscoreLayoutError); !ok {
+			t.Errorf("Expected HiscoreLayoutError, got %v", err)
+		} else if layoutErr.Actual != 30 {
+			t.Errorf("Expected 30 rows reported, got %d", layoutErr.Actual)
+		}
+	})
+
+	t.Run("ExtraRows", func(t *testing.T) {
+		_, err := parseCSVHiscores(validCSV + " -1,-1")
+
+		if _, ok := err.(*HiscoreLayoutError); !ok {
+			t.Errorf("Expected HiscoreLayoutError, got %v", err)
+		}
+	})
+
+	t.Run("MalformedRow", func(t *testing.T) {
+		malformed := make([]string, len(rows))
+		copy(malformed, rows)
+		malformed[4] = "505273,eighty-six,3732405"
+		_, err := parseCSVHiscores(strings.Join(malformed, " "))
+
+		if layoutErr, ok := err.(*HiscoreLayoutError); !ok {
+			t.Errorf("Expected HiscoreLayoutError, got %v", err)
+		} else if layoutErr.Entry != "Hitpoints" {
+			t.Errorf("Expected malformed row Hitpoints, got %s", layoutErr.Entry)
+		}
+	})
+
+	t.Run("MissingField", func(t *testing.T) {
+		malformed := make([]string, len(rows))
+		copy(malformed, rows)
+		malformed[26] = "3308"
+		_, err := parseCSVHiscores(strings.Join(malformed, " "))
+
+		if _, ok := err.(*HiscoreLayoutError); !ok {
+			t.Errorf("Expected HiscoreLayoutError, got %v", err)
+		}
+	})
+}
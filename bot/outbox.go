@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gempir/go-twitch-irc"
+)
+
+const (
+	// RateLimitWindow Window Twitch limits the messages sent by an account within
+	RateLimitWindow = 30 * time.Second
+	// RateLimit Messages an account can send within RateLimitWindow
+	RateLimit = 20
+	// ModeratorRateLimit Messages an account can send within RateLimitWindow when
+	// they're all in channels it moderates
+	ModeratorRateLimit = 100
+)
+
+// slidingWindow Allows up to limit actions within any span of time as long as
+// window, like Twitch counts messages
+type slidingWindow struct {
+	limit  int
+	window time.Duration
+	// taken Times of the actions within the last window, oldest first
+	taken []time.Time
+}
+
+// newSlidingWindow Returns a slidingWindow with no actions taken
+func newSlidingWindow(limit int, window time.Duration) *slidingWindow {
+	return &slidingWindow{
+		limit:  limit,
+		window: window,
+	}
+}
+
+// wait Returns how long until another action is allowed, 0 if one is allowed now
+func (sliding *slidingWindow) wait(now time.Time) time.Duration {
+	expired := 0
+	for expired < len(sliding.taken) && !now.Before(sliding.taken[expired].Add(sliding.window)) {
+		expired++
+	}
+	sliding.taken = sliding.taken[expired:]
+
+	if len(sliding.taken) < sliding.limit {
+		return 0
+	}
+
+	// The oldest action has to leave the window first
+	return sliding.taken[0].Add(sliding.window).Sub(now)
+}
+
+// take Records an action taken at now
+func (sliding *slidingWindow) take(now time.Time) {
+	sliding.taken = append(sliding.taken, now)
+}
+
+// outboundMessage Message waiting in an Outbox
+type outboundMessage struct {
+	text   string
+	queued time.Time
+}
+
+// Outbox Queue of messages to send through an IRC client within Twitch's rate
+// limits. Every message counts towards the limit for all channels, and messages
+// in channels the account doesn't moderate also count towards the stricter limit
+// for those. Channels take turns sending their oldest message, so a busy channel can't
+// hold up the rest, and messages that waited longer than MaxAge are dropped
+type Outbox struct {
+	Client IRC
+	MaxAge time.Duration
+
+	mutex       sync.Mutex
+	queues      map[string][]outboundMessage
+	channels    []string
+	turn        int
+	moderated   map[string]bool
+	all         *slidingWindow
+	unmoderated *slidingWindow
+	wake        chan struct{}
+}
+
+// NewOutbox Returns an empty Outbox sending through client, with Twitch's rate
+// limits applied over window
+func NewOutbox(client IRC, window, maxAge time.Duration) *Outbox {
+	return &Outbox{
+		Client:      client,
+		MaxAge:      maxAge,
+		queues:      map[string][]outboundMessage{},
+		moderated:   map[string]bool{},
+		all:         newSlidingWindow(ModeratorRateLimit, window),
+		unmoderated: newSlidingWindow(RateLimit, window),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Send Queues text to be sent to the channel
+func (outbox *Outbox) Send(channel, text string) {
+	outbox.mutex.Lock()
+	if _, ok := outbox.queues[channel]; !ok {
+		outbox.channels = append(outbox.channels, channel)
+	}
+	outbox.queues[channel] = append(outbox.queues[channel], outboundMessage{text, time.Now()})
+	outbox.mutex.Unlock()
+
+	// The Outbox only needs waking once for any number of new messages
+	select {
+	case outbox.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SetModerated Sets whether the account moderates the channel, which raises its
+// rate limit there
+func (outbox *Outbox) SetModerated(channel string, moderated bool) {
+	outbox.mutex.Lock()
+	defer outbox.mutex.Unlock()
+
+	outbox.moderated[channel] = moderated
+}
+
+// HandleUserstate Callback for USERSTATE messages, which Twitch sends with the
+// account's badges on joining a channel and after each message it sends there
+func (outbox *Outbox) HandleUserstate(channel string, user twitch.User, message twitch.Message) {
+	outbox.SetModerated(channel, UserPermission(user) >= PermissionModerator)
+}
+
+// Run Sends queued messages as the rate limits allow, until stop is closed
+func (outbox *Outbox) Run(stop <-chan struct{}) {
+	for {
+		channel, text, wait, ok := outbox.next()
+
+		if ok {
+			outbox.Client.Say(channel, text)
+			continue
+		}
+
+		// Waits until a message can be sent, or with nothing queued, until one is
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = time.After(wait)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-outbox.wake:
+		case <-timer:
+		}
+	}
+}
+
+// next Takes the next message that can be sent now, going through the channels in
+// turn. If none can, returns false with how long until one can, or 0 if nothing is
+// queued
+func (outbox *Outbox) next() (string, string, time.Duration, bool) {
+	outbox.mutex.Lock()
+	defer outbox.mutex.Unlock()
+
+	now := time.Now()
+	outbox.dropStale(now)
+
+	var wait time.Duration
+	for i := range outbox.channels {
+		index := (outbox.turn + i) % len(outbox.channels)
+		channel := outbox.channels[index]
+
+		channelWait := outbox.all.wait(now)
+		if !outbox.moderated[channel] {
+			if unmoderatedWait := outbox.unmoderated.wait(now); unmoderatedWait > channelWait {
+				channelWait = unmoderatedWait
+			}
+		}
+
+		if channelWait > 0 {
+			if wait == 0 || channelWait < wait {
+				wait = channelWait
+			}
+			continue
+		}
+
+		outbox.all.take(now)
+		if !outbox.moderated[channel] {
+			outbox.unmoderated.take(now)
+		}
+
+		message := outbox.queues[channel][0]
+		outbox.queues[channel] = outbox.queues[channel][1:]
+		outbox.turn = index + 1
+
+		return channel, message.text, 0, true
+	}
+
+	return "", "", wait, false
+}
+
+// dropStale Drops the messages that have waited longer than MaxAge, and forgets
+// the channels with nothing queued
+func (outbox *Outbox) dropStale(now time.Time) {
+	channels := outbox.channels[:0]
+
+	for i, channel := range outbox.channels {
+		queue := outbox.queues[channel]
+
+		// Messages are queued in order, so the stale ones come first
+		stale := 0
+		for stale < len(queue) && outbox.MaxAge > 0 && now.Sub(queue[stale].queued) > outbox.MaxAge {
+			stale++
+		}
+
+		if stale > 0 {
+			log.Printf("Dropping %d messages to channel %s that waited longer than %s", stale, channel, outbox.MaxAge)
+			queue = queue[stale:]
+		}
+
+		if len(queue) == 0 {
+			delete(outbox.queues, channel)
+			if i < outbox.turn {
+				outbox.turn--
+			}
+			continue
+		}
+
+		outbox.queues[channel] = queue
+		channels = append(channels, channel)
+	}
+
+	outbox.channels = channels
+}
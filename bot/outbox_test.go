@@ -0,0 +1,163 @@
+package bot
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc"
+)
+
+// recordingIRC Records every message said, without blocking
+type recordingIRC struct {
+	mockIRC
+
+	mutex sync.Mutex
+	said  []string
+}
+
+func (irc *recordingIRC) Say(channel, text string) {
+	irc.mutex.Lock()
+	defer irc.mutex.Unlock()
+
+	irc.said = append(irc.said, fmt.Sprintf("%s: %s", channel, text))
+}
+
+func (irc *recordingIRC) Said() []string {
+	irc.mutex.Lock()
+	defer irc.mutex.Unlock()
+
+	return append([]string{}, irc.said...)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	sliding := newSlidingWindow(RateLimit, RateLimitWindow)
+	now := time.Unix(0, 0)
+
+	// Takes every action as soon as it's allowed
+	taken := []time.Time{}
+	for len(taken) < 3*RateLimit+5 {
+		now = now.Add(sliding.wait(now))
+		sliding.take(now)
+		taken = append(taken, now)
+	}
+
+	for i, start := range taken {
+		within := 0
+		for _, at := range taken[i:] {
+			if at.Sub(start) < RateLimitWindow {
+				within++
+			}
+		}
+
+		if within > RateLimit {
+			t.Fatalf("Allowed %d actions within %s of %s", within, RateLimitWindow, start)
+		}
+	}
+
+	if elapsed := now.Sub(time.Unix(0, 0)); elapsed != 3*RateLimitWindow {
+		t.Errorf("Expected the last actions to be allowed after %s, found %s", 3*RateLimitWindow, elapsed)
+	}
+}
+
+// runOutbox Runs the outbox until the returned channel is closed
+func runOutbox(outbox *Outbox) chan struct{} {
+	stop := make(chan struct{})
+	go outbox.Run(stop)
+	return stop
+}
+
+func TestOutbox(t *testing.T) {
+	t.Run("RateLimit", func(t *testing.T) {
+		irc := &recordingIRC{}
+		outbox := NewOutbox(irc, time.Second, time.Minute)
+		defer close(runOutbox(outbox))
+
+		for i := 0; i < RateLimit+5; i++ {
+			outbox.Send("channel", fmt.Sprint(i))
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		if said := irc.Said(); len(said) != RateLimit {
+			t.Errorf("Expected %d messages within the limit, found %d", RateLimit, len(said))
+		}
+
+		// Nothing more is sent until the first messages leave the window
+		time.Sleep(300 * time.Millisecond)
+		if said := irc.Said(); len(said) != RateLimit {
+			t.Errorf("Expected %d messages within the window, found %d", RateLimit, len(said))
+		}
+
+		time.Sleep(time.Second)
+		if said := irc.Said(); len(said) != RateLimit+5 {
+			t.Errorf("Expected %d messages after the window, found %d", RateLimit+5, len(said))
+		}
+	})
+
+	t.Run("Moderated", func(t *testing.T) {
+		irc := &recordingIRC{}
+		outbox := NewOutbox(irc, 2*time.Second, time.Minute)
+		outbox.HandleUserstate("channel", twitch.User{Badges: map[string]int{"moderator": 1}}, twitch.Message{})
+		defer close(runOutbox(outbox))
+
+		for i := 0; i < RateLimit+5; i++ {
+			outbox.Send("channel", fmt.Sprint(i))
+		}
+		outbox.Send("other", "unmoderated")
+
+		time.Sleep(50 * time.Millisecond)
+		if said := irc.Said(); len(said) != RateLimit+6 {
+			t.Errorf("Expected %d messages within the moderator limit, found %d", RateLimit+6, len(said))
+		}
+	})
+
+	t.Run("Turns", func(t *testing.T) {
+		irc := &recordingIRC{}
+		outbox := NewOutbox(irc, 2*time.Second, time.Minute)
+
+		for i := 0; i < 3; i++ {
+			outbox.Send("busy", fmt.Sprint(i))
+		}
+		outbox.Send("quiet", "0")
+		defer close(runOutbox(outbox))
+
+		time.Sleep(50 * time.Millisecond)
+		expected := []string{"busy: 0", "quiet: 0", "busy: 1", "busy: 2"}
+		if said := irc.Said(); !reflect.DeepEqual(said, expected) {
+			t.Errorf("Expected messages %v, found %v", expected, said)
+		}
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		irc := &recordingIRC{}
+		outbox := NewOutbox(irc, 2*time.Second, 10*time.Millisecond)
+
+		outbox.Send("channel", "stale")
+		time.Sleep(20 * time.Millisecond)
+		defer close(runOutbox(outbox))
+		outbox.Send("channel", "fresh")
+
+		time.Sleep(50 * time.Millisecond)
+		expected := []string{"channel: fresh"}
+		if said := irc.Said(); !reflect.DeepEqual(said, expected) {
+			t.Errorf("Expected messages %v, found %v", expected, said)
+		}
+	})
+
+	t.Run("Say", func(t *testing.T) {
+		bot := NewMockBot()
+		irc := &recordingIRC{}
+		bot.Outbox = NewOutbox(irc, 2*time.Second, time.Minute)
+		defer close(runOutbox(bot.Outbox))
+
+		bot.Say("channel", "message")
+
+		time.Sleep(50 * time.Millisecond)
+		expected := []string{"channel: /me message"}
+		if said := irc.Said(); !reflect.DeepEqual(said, expected) {
+			t.Errorf("Expected messages %v, found %v", expected, said)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -12,6 +13,16 @@ import (
 	"github.com/gempir/go-twitch-irc"
 )
 
+const (
+	// MaxMessageLength Longest chat message Twitch accepts
+	MaxMessageLength = 500
+
+	// sayPrefix Prefix Say adds to every message
+	sayPrefix = "/me "
+	// maxSayLength Longest text that can be given to Say
+	maxSayLength = MaxMessageLength - len(sayPrefix)
+)
+
 var (
 	// TableName Name of the table holding Channel records in DynamoDB
 	TableName string = "ob-channels"
@@ -28,6 +39,30 @@ type OziachBot struct {
 	TwitchClient IRC
 	ChannelDB    ChannelDatabase
 	HiscoreAPI   *HiscoreAPI
+
+	// OutageNotices Limits replies saying the Hiscore API is down to one per
+	// channel per window. Every failed lookup gets a reply if nil
+	OutageNotices *Throttle
+
+	// Snapshots Stores snapshots of looked up players for !gained, which is
+	// disabled if nil
+	Snapshots SnapshotStore
+	// RecentSnapshots Limits snapshots recorded on lookups to one per player per
+	// window. Every lookup is recorded if nil
+	RecentSnapshots *Throttle
+	// JoinTimes Times OziachBot joined each channel, for !gained stream
+	JoinTimes *JoinTimes
+
+	// Commands Commands OziachBot responds to. The built-in commands are used if
+	// nil
+	Commands *CommandRegistry
+	// Cooldowns Tracks commands on cooldown in each channel. Cooldowns aren't
+	// enforced if nil
+	Cooldowns *CommandCooldowns
+
+	// Outbox Queues messages to send them within Twitch's rate limits. Messages
+	// are sent as soon as they're said if nil
+	Outbox *Outbox
 }
 
 // IRC Interface for interaction with an IRC Server
@@ -57,9 +92,36 @@ type DynamoDBChannelDatabase struct {
 
 // Channel DynamoDB schema for channel records
 type Channel struct {
-	Name        string `json:"name"`
-	IsConnected bool   `json:"isConnected"`
-	RSN         string `json:"rsn"`
+	Name        string          `json:"name"`
+	IsConnected bool            `json:"isConnected"`
+	RSN         string          `json:"rsn"`
+	Settings    ChannelSettings `json:"settings"`
+	Session     *Session        `json:"session,omitempty"`
+	// Mode Name of the GameMode last detected for the RSN
+	Mode string `json:"mode,omitempty"`
+	// Aliases Skill names set by the channel's mods, mapping each alias to the
+	// official name of its skill
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// Permissions Overrides of the Permission needed to run commands, mapping the
+	// path of each command to the name of its Permission
+	Permissions map[string]string `json:"permissions,omitempty"`
+}
+
+// ChannelSettings Toggles for optional details in OziachBot's replies in a channel.
+// Everything is off for a new channel
+type ChannelSettings struct {
+	// VirtualLevels Show virtual levels past 99 in skill lookups
+	VirtualLevels bool `json:"virtualLevels"`
+	// ExpToNextLevel Show the exp remaining until the next level in skill lookups
+	ExpToNextLevel bool `json:"expToNextLevel"`
+	// PercentToMax Show progress towards 99, or 200M exp past 99, in skill lookups
+	PercentToMax bool `json:"percentToMax"`
+	// Announcements Announce level ups and milestones of the channel RSN
+	Announcements bool `json:"announcements"`
+	// AnnounceStatusLoss Announce the channel RSN losing Hardcore status
+	AnnounceStatusLoss bool `json:"announceStatusLoss"`
+	// Cooldowns Cooldowns of commands, by the path of each command
+	Cooldowns map[string]Cooldown `json:"cooldowns,omitempty"`
 }
 
 // UnmarshalChannel Convenience method to unmarshal a DynamoDB record directly
@@ -267,7 +329,9 @@ func (bot *OziachBot) ConnectToChannel(name string) error {
 
 	if err == nil {
 		bot.TwitchClient.Join(channel.Name)
+		bot.JoinTimes.Set(channel.Name, time.Now())
 		log.Println("Connection successful")
+		go bot.startJoinSession(channel, false)
 	} else {
 		log.Println("Connection failed")
 	}
@@ -277,16 +341,42 @@ func (bot *OziachBot) ConnectToChannel(name string) error {
 
 // ChangeRSN Updates an existing channel by setting rsn
 func (bot *OziachBot) ChangeRSN(name, rsn string) error {
-	// Expression builder to set rsn
+	player, err := ParseRSN(rsn)
+	if err != nil {
+		return err
+	}
+
+	// Expression builder to set rsn, forgetting the GameMode and the session of
+	// the old one
 	builder := expression.NewBuilder().WithUpdate(
-		expression.Set(expression.Name("rsn"), expression.Value(rsn)),
+		expression.Set(expression.Name("rsn"), expression.Value(player)).
+			Remove(expression.Name("mode")).
+			Remove(expression.Name("session")),
 	)
 
-	log.Printf("Attempting to change rsn of channel %s to %s", name, rsn)
-	_, err := bot.ChannelDB.UpdateChannel(name, builder)
+	log.Printf("Attempting to change rsn of channel %s to %s", name, player)
+	_, err = bot.ChannelDB.UpdateChannel(name, builder)
 	return err
 }
 
+// ChangeSettings Updates an existing channel by replacing its settings. Cooldowns
+// must be for registered commands
+func (bot *OziachBot) ChangeSettings(name string, settings ChannelSettings) (Channel, error) {
+	cooldowns, err := bot.resolveCooldowns(settings.Cooldowns)
+	if err != nil {
+		return Channel{}, err
+	}
+	settings.Cooldowns = cooldowns
+
+	// Expression builder to set settings
+	builder := expression.NewBuilder().WithUpdate(
+		expression.Set(expression.Name("settings"), expression.Value(settings)),
+	)
+
+	log.Printf("Attempting to change settings of channel %s to %+v", name, settings)
+	return bot.ChannelDB.UpdateChannel(name, builder)
+}
+
 // InitBot Initalizes OziachBot by querying for channels and joining them
 func (bot *OziachBot) InitBot() error {
 	log.Println("Reading channels from DB")
@@ -299,12 +389,17 @@ func (bot *OziachBot) InitBot() error {
 	// Join all rooms from the DB query
 	log.Println("Joining channels")
 
+	joined := []Channel{}
 	for _, channel := range channels {
 		if channel.IsConnected {
 			bot.TwitchClient.Join(channel.Name)
+			bot.JoinTimes.Set(channel.Name, time.Now())
+			joined = append(joined, channel)
 		}
 	}
 
+	go bot.startJoinSessions(joined, joinSessionGap)
+
 	return nil
 }
 
@@ -313,62 +408,19 @@ func (bot *OziachBot) HandleMessage(channel string, user twitch.User, message tw
 	// Only handle message if the user is not a bot and not an ignored user
 	if _, ok := ignored[user.Username]; !strings.HasSuffix(user.Username, "bot") && !ok {
 		log.Printf("Handling message \"%s\" from channel %s\n", message.Text, channel)
-		idx := strings.Index(message.Text, " ")
-		if idx == -1 {
-			idx = len(message.Text)
-		}
-
-		switch message.Text[:idx] {
-		case "!lvl", "!level":
-			numToks := 3
-			tokens := strings.SplitN(message.Text, " ", numToks)
-
-			// Append the channel RSN if it's "nonzero"
-			// This puts the RSN in the right place if one is not provided, and
-			// lets it be ignored if one is provided
-			obUser, _ := bot.ChannelDB.GetChannel(channel)
-			if obUser.RSN != "" {
-				tokens = append(tokens, obUser.RSN)
-			}
-
-			if len(tokens) >= numToks {
-				skillName := tokens[1]
-				player := tokens[2]
-
-				if len(player) > 12 {
-					player = player[:12]
-				}
-
-				go bot.HandleSkillLookup(channel, user.DisplayName, skillName, player)
-			}
-		case "!total", "!overall":
-			numToks := 2
-			tokens := strings.SplitN(message.Text, " ", numToks)
-
-			// Append the channel RSN if it's "nonzero"
-			// This puts the RSN in the right place if one is not provided, and
-			// lets it be ignored if one is provided
-			obUser, _ := bot.ChannelDB.GetChannel(channel)
-			if obUser.RSN != "" {
-				tokens = append(tokens, obUser.RSN)
-			}
-
-			if len(tokens) >= numToks {
-				skillName := "overall"
-				player := tokens[1]
-
-				if len(player) > 12 {
-					player = player[:12]
-				}
-
-				go bot.HandleSkillLookup(channel, user.DisplayName, skillName, player)
-			}
-		}
+		bot.Dispatch(channel, user, message.Text)
 	}
 }
 
-// Say Wrapper for Client.Say that prefixes the text with "/me"
+// Say Wrapper for Client.Say that prefixes the text with "/me", queueing it in
+// the Outbox if there is one
 func (bot *OziachBot) Say(channel, text string) {
-	formattedText := fmt.Sprintf("/me %s", text)
+	formattedText := fmt.Sprintf("%s%s", sayPrefix, text)
+
+	if bot.Outbox != nil {
+		bot.Outbox.Send(channel, formattedText)
+		return
+	}
+
 	bot.TwitchClient.Say(channel, formattedText)
 }
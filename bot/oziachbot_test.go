@@ -2,9 +2,14 @@ package bot
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 	"github.com/gempir/go-twitch-irc"
 )
@@ -72,6 +77,216 @@ func (db *mockChannelDB) UpdateChannel(name string, builder expression.Builder)
 	}
 }
 
+// storedChannelDB Holds channel records by name, applying updates to the
+// attributes they name like DynamoDB does. Updates fail with updateErr when it's set
+type storedChannelDB struct {
+	mutex     sync.Mutex
+	names     []string
+	items     map[string]map[string]*dynamodb.AttributeValue
+	updateErr error
+}
+
+// updateActionPattern Matches the SET values of an update expression, either a
+// value or if_not_exists of a path and a value
+var updateActionPattern = regexp.MustCompile(`([#\w.]+) = (?:if_not_exists\(([#\w.]+), (:\w+)\)|(:\w+))`)
+
+// newStoredChannelDB Returns a storedChannelDB holding channels
+func newStoredChannelDB(channels ...Channel) *storedChannelDB {
+	db := &storedChannelDB{items: map[string]map[string]*dynamodb.AttributeValue{}}
+
+	for _, channel := range channels {
+		db.put(channel)
+	}
+
+	return db
+}
+
+// put Stores channel, replacing the record with the same name
+func (db *storedChannelDB) put(channel Channel) {
+	item, err := dynamodbattribute.MarshalMap(channel)
+	if err != nil {
+		panic(err)
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if _, ok := db.items[channel.Name]; !ok {
+		db.names = append(db.names, channel.Name)
+	}
+	db.items[channel.Name] = item
+}
+
+// channel Returns the stored record of name
+func (db *storedChannelDB) channel(name string) Channel {
+	channel, _ := db.GetChannel(name)
+	return channel
+}
+
+// failUpdates Makes every update fail with err, or succeed again if it's nil
+func (db *storedChannelDB) failUpdates(err error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	db.updateErr = err
+}
+
+func (db *storedChannelDB) GetChannel(name string) (Channel, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	item, ok := db.items[name]
+	if !ok {
+		return Channel{}, ChannelNotFoundError{name}
+	}
+
+	return UnmarshalChannel(item)
+}
+
+func (db *storedChannelDB) GetAllChannels() ([]Channel, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	channels := make([]Channel, len(db.names))
+	for i, name := range db.names {
+		channel, err := UnmarshalChannel(db.items[name])
+		if err != nil {
+			return nil, err
+		}
+		channels[i] = channel
+	}
+
+	return channels, nil
+}
+
+func (db *storedChannelDB) AddChannel(name string) (Channel, error) {
+	if channel, err := db.GetChannel(name); err == nil {
+		return channel, ChannelAlreadyExistsError{name}
+	}
+
+	channel := Channel{Name: name}
+	db.put(channel)
+	return channel, nil
+}
+
+func (db *storedChannelDB) UpdateChannel(name string, builder expression.Builder) (Channel, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if db.updateErr != nil {
+		return Channel{}, db.updateErr
+	}
+
+	item, ok := db.items[name]
+	if !ok {
+		return Channel{}, ChannelNotFoundError{name}
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return Channel{}, err
+	}
+
+	// Applied to a copy, so a failed update leaves the record as it was
+	updated := copyAttribute(&dynamodb.AttributeValue{M: item}).M
+	path := func(names string) []string {
+		path := strings.Split(names, ".")
+		for i, placeholder := range path {
+			path[i] = *expr.Names()[placeholder]
+		}
+		return path
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(*expr.Update()), "\n") {
+		parts := strings.SplitN(line, " ", 2)
+		action, actions := parts[0], parts[1]
+
+		switch action {
+		case "SET":
+			for _, match := range updateActionPattern.FindAllStringSubmatch(actions, -1) {
+				if match[2] != "" {
+					if _, ok := getAttribute(updated, path(match[2])); ok {
+						continue
+					}
+					match[4] = match[3]
+				}
+
+				if err := setAttribute(updated, path(match[1]), expr.Values()[match[4]]); err != nil {
+					return Channel{}, err
+				}
+			}
+		case "REMOVE":
+			for _, names := range strings.Split(actions, ", ") {
+				if err := setAttribute(updated, path(names), nil); err != nil {
+					return Channel{}, err
+				}
+			}
+		default:
+			return Channel{}, fmt.Errorf("Unsupported update action %s", action)
+		}
+	}
+
+	db.items[name] = updated
+	return UnmarshalChannel(updated)
+}
+
+// copyAttribute Returns a deep copy of value, so nested updates don't change it
+func copyAttribute(value *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	copied := *value
+
+	if value.M != nil {
+		copied.M = map[string]*dynamodb.AttributeValue{}
+		for key, nested := range value.M {
+			copied.M[key] = copyAttribute(nested)
+		}
+	}
+
+	if value.L != nil {
+		copied.L = make([]*dynamodb.AttributeValue, len(value.L))
+		for i, nested := range value.L {
+			copied.L[i] = copyAttribute(nested)
+		}
+	}
+
+	return &copied
+}
+
+// getAttribute Returns the value at path in item, if there is one
+func getAttribute(item map[string]*dynamodb.AttributeValue, path []string) (*dynamodb.AttributeValue, bool) {
+	value := &dynamodb.AttributeValue{M: item}
+
+	for _, name := range path {
+		if value.M == nil {
+			return nil, false
+		}
+
+		nested, ok := value.M[name]
+		if !ok {
+			return nil, false
+		}
+		value = nested
+	}
+
+	return value, true
+}
+
+// setAttribute Sets the value at path in item, removing it if value is nil.
+// Like DynamoDB, the maps the path goes through must already exist
+func setAttribute(item map[string]*dynamodb.AttributeValue, path []string, value *dynamodb.AttributeValue) error {
+	parent, ok := getAttribute(item, path[:len(path)-1])
+	if !ok || parent.M == nil {
+		return fmt.Errorf("The document path provided in the update expression is invalid for update")
+	}
+
+	if value == nil {
+		delete(parent.M, path[len(path)-1])
+	} else {
+		parent.M[path[len(path)-1]] = value
+	}
+
+	return nil
+}
+
 type mockIRC struct {
 	messageChan chan string
 	joinChan    chan string
@@ -265,6 +480,7 @@ func TestHandleMessage(t *testing.T) {
 					Level: 90,
 					Exp:   5866885,
 				},
+				ChannelSettings{},
 			)
 
 			go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
@@ -302,6 +518,107 @@ func TestHandleMessage(t *testing.T) {
 			}
 		})
 
+		t.Run("MisspelledSkill", func(t *testing.T) {
+			testMessage := twitch.Message{
+				Text: fmt.Sprintf("!lvl rangd %s", ironmanAccount),
+			}
+
+			expected := "/me " + FormatSkillLookupOutput(
+				testUser.DisplayName,
+				ironmanAccount,
+				"Ranged",
+				GameModeIronman,
+				SkillHiscore{
+					Rank:  342695,
+					Level: 90,
+					Exp:   5866885,
+				},
+				ChannelSettings{},
+			)
+
+			go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
+
+			select {
+			case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+				if resp != expected {
+					t.Errorf("Said %s, but expected to say %s", resp, expected)
+				}
+			case <-time.After(3 * time.Second):
+				t.Error("Message handling unsuccessful due to timeout")
+			}
+		})
+
+		t.Run("AmbiguousSkill", func(t *testing.T) {
+			testMessage := twitch.Message{
+				Text: fmt.Sprintf("!lvl cok %s", ironmanAccount),
+			}
+
+			expected := fmt.Sprintf(
+				"/me @%s Unknown skill cok, did you mean Cooking or Construction?",
+				testUser.DisplayName,
+			)
+
+			go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
+
+			select {
+			case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+				if resp != expected {
+					t.Errorf("Said %s, but expected to say %s", resp, expected)
+				}
+			case <-time.After(3 * time.Second):
+				t.Error("Message handling unsuccessful due to timeout")
+			}
+		})
+
+		t.Run("SeparatedPlayer", func(t *testing.T) {
+			testMessage := twitch.Message{
+				Text: "!lvl ranged Fallen_HCIM",
+			}
+
+			expected := "/me " + FormatSkillLookupOutput(
+				testUser.DisplayName,
+				fallenHardcoreAccount,
+				"Ranged",
+				GameModeFormerHardcoreIronman,
+				SkillHiscore{
+					Rank:  342695,
+					Level: 90,
+					Exp:   5866885,
+				},
+				ChannelSettings{},
+			)
+
+			go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
+
+			select {
+			case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+				if resp != expected {
+					t.Errorf("Said %s, but expected to say %s", resp, expected)
+				}
+			case <-time.After(3 * time.Second):
+				t.Error("Message handling unsuccessful due to timeout")
+			}
+		})
+
+		t.Run("InvalidRSN", func(t *testing.T) {
+			testMessage := twitch.Message{
+				Text: "!lvl ranged Not.An.RSN",
+			}
+
+			expected := "/me @TestUser Not.An.RSN is not a valid RSN"
+
+			go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
+
+			select {
+			case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+				if resp != expected {
+					t.Errorf("Said %s, but expected to say %s", resp, expected)
+				}
+			case <-time.After(3 * time.Second):
+				t.Error("Message handling unsuccessful due to timeout")
+			}
+		})
+
 		t.Run("InvalidSkill", func(t *testing.T) {
 			testMessage := twitch.Message{
 				Text: fmt.Sprintf("!lvl sailing %s", hardcoreAccount),
@@ -344,6 +661,7 @@ func TestHandleMessage(t *testing.T) {
 					Level: 922,
 					Exp:   26362111,
 				},
+				ChannelSettings{},
 			)
 
 			go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
@@ -381,4 +699,298 @@ func TestHandleMessage(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("KillCountCommand", func(t *testing.T) {
+		testUser := twitch.User{
+			Username:    "testuser",
+			DisplayName: "TestUser",
+		}
+
+		t.Run("ValidInvocation", func(t *testing.T) {
+			testMessage := twitch.Message{
+				Text: fmt.Sprintf("!kc snek %s", ironmanAccount),
+			}
+
+			expected := "/me " + FormatKillCountLookupOutput(
+				testUser.DisplayName,
+				ironmanAccount,
+				"Zulrah",
+				GameModeIronman,
+				MinigameHiscore{
+					Rank:  4321,
+					Score: 512,
+				},
+			)
+
+			go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
+
+			select {
+			case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+				if resp != expected {
+					t.Errorf("Said %s, but expected to say %s", resp, expected)
+				}
+			case <-time.After(3 * time.Second):
+				t.Error("Message handling unsuccessful due to timeout")
+			}
+		})
+
+		t.Run("Unranked", func(t *testing.T) {
+			testMessage := twitch.Message{
+				Text: fmt.Sprintf("!kc tob %s", ironmanAccount),
+			}
+
+			expected := fmt.Sprintf(
+				"/me @%s %s is not ranked in Theatre of Blood kill count",
+				testUser.DisplayName,
+				ironmanAccount,
+			)
+
+			go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
+
+			select {
+			case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+				if resp != expected {
+					t.Errorf("Said %s, but expected to say %s", resp, expected)
+				}
+			case <-time.After(3 * time.Second):
+				t.Error("Message handling unsuccessful due to timeout")
+			}
+		})
+	})
+
+	t.Run("CombatCommand", func(t *testing.T) {
+		testUser := twitch.User{
+			Username:    "testuser",
+			DisplayName: "TestUser",
+		}
+		testMessage := twitch.Message{
+			Text: fmt.Sprintf("!cb %s", ironmanAccount),
+		}
+
+		expected := fmt.Sprintf(
+			"/me @%s - %s | Combat level: 74 (Magic) | Next level: +2 Magic, +4 Defense, "+
+				"+4 Hitpoints, +7 Attack, +7 Strength, +7 Prayer, +8 Ranged",
+			testUser.DisplayName,
+			ironmanAccount,
+		)
+
+		go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
+
+		select {
+		case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+			if resp != expected {
+				t.Errorf("Said %s, but expected to say %s", resp, expected)
+			}
+		case <-time.After(3 * time.Second):
+			t.Error("Message handling unsuccessful due to timeout")
+		}
+	})
+
+	t.Run("StatsCommand", func(t *testing.T) {
+		testUser := twitch.User{
+			Username:    "testuser",
+			DisplayName: "TestUser",
+		}
+		testMessage := twitch.Message{
+			Text: fmt.Sprintf("!stats %s", ironmanAccount),
+		}
+
+		hiscores, _ := bot.HiscoreAPI.LookupHiscoresByGameMode(ironmanAccount, GameModeIronman)
+		expected := "/me " + FormatStatsLookupOutput(testUser.DisplayName, ironmanAccount, GameModeIronman, hiscores)[0]
+
+		go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
+
+		select {
+		case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+			if resp != expected {
+				t.Errorf("Said %s, but expected to say %s", resp, expected)
+			}
+
+			if len(resp) > MaxMessageLength {
+				t.Errorf("Said %d characters, over the limit of %d", len(resp), MaxMessageLength)
+			}
+		case <-time.After(3 * time.Second):
+			t.Error("Message handling unsuccessful due to timeout")
+		}
+	})
+
+	t.Run("MinigameCommands", func(t *testing.T) {
+		testUser := twitch.User{
+			Username:    "testuser",
+			DisplayName: "TestUser",
+		}
+
+		testCases := []struct {
+			Name     string
+			Text     string
+			Expected string
+		}{
+			{
+				"Clues",
+				fmt.Sprintf("!clues %s", ironmanAccount),
+				fmt.Sprintf("/me @TestUser %s is not ranked in Clue scrolls (all)", ironmanAccount),
+			},
+			{
+				"ClueTier",
+				fmt.Sprintf("!clues master %s", ironmanAccount),
+				fmt.Sprintf("/me @TestUser %s is not ranked in Clue scrolls (master)", ironmanAccount),
+			},
+			{
+				"LMS",
+				fmt.Sprintf("!lms %s", ironmanAccount),
+				fmt.Sprintf("/me @TestUser - %s | Last Man Standing: 992 | Rank (Ironman): 3,308", ironmanAccount),
+			},
+			{
+				"BountyHunter",
+				fmt.Sprintf("!bh %s", ironmanAccount),
+				fmt.Sprintf(
+					"/me @TestUser - %s | Bounty Hunter (Ironman) | Hunter: 661 (Rank 5,106) | Rogue: 36 (Rank 30,275)",
+					ironmanAccount,
+				),
+			},
+			{
+				"BountyHunterRogue",
+				fmt.Sprintf("!bh rogue %s", ironmanAccount),
+				fmt.Sprintf("/me @TestUser - %s | Bounty Hunter - Rogue: 36 | Rank (Ironman): 30,275", ironmanAccount),
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.Name, func(t *testing.T) {
+				go bot.HandleMessage("whatever channel doesn't matter", testUser, twitch.Message{Text: tc.Text})
+
+				select {
+				case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+					if resp != tc.Expected {
+						t.Errorf("Said %s, but expected to say %s", resp, tc.Expected)
+					}
+				case <-time.After(3 * time.Second):
+					t.Error("Message handling unsuccessful due to timeout")
+				}
+			})
+		}
+	})
+
+	t.Run("CompareCommand", func(t *testing.T) {
+		testUser := twitch.User{
+			Username:    "testuser",
+			DisplayName: "TestUser",
+		}
+		testMessage := twitch.Message{
+			Text: fmt.Sprintf("!compare range %s %s", normalAccount, ironmanAccount),
+		}
+
+		skill := SkillHiscore{Rank: 342695, Level: 90, Exp: 5866885}
+		expected := "/me " + FormatSkillComparisonOutput(
+			testUser.DisplayName,
+			"Ranged",
+			normalAccount,
+			skill,
+			ironmanAccount,
+			skill,
+		)
+
+		go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
+
+		select {
+		case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+			if resp != expected {
+				t.Errorf("Said %s, but expected to say %s", resp, expected)
+			}
+		case <-time.After(3 * time.Second):
+			t.Error("Message handling unsuccessful due to timeout")
+		}
+	})
+
+	t.Run("CalculatorCommands", func(t *testing.T) {
+		testUser := twitch.User{
+			Username:    "testuser",
+			DisplayName: "TestUser",
+		}
+
+		testCases := []struct {
+			Name     string
+			Text     string
+			Expected string
+		}{
+			{"Exp", "!xp 99", "/me @TestUser Level 99 requires 13,034,431 exp"},
+			{"Level", "!level 13.5m", "/me @TestUser 13,500,000 exp is level 99 | Exp to 100: 891,160"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.Name, func(t *testing.T) {
+				go bot.HandleMessage("whatever channel doesn't matter", testUser, twitch.Message{Text: tc.Text})
+
+				select {
+				case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+					if resp != tc.Expected {
+						t.Errorf("Said %s, but expected to say %s", resp, tc.Expected)
+					}
+				case <-time.After(3 * time.Second):
+					t.Error("Message handling unsuccessful due to timeout")
+				}
+			})
+		}
+
+		t.Run("OutOfRange", func(t *testing.T) {
+			wait := make(chan struct{})
+			go func() {
+				bot.HandleMessage("whatever channel doesn't matter", testUser, twitch.Message{Text: "!xp 127"})
+				wait <- struct{}{}
+			}()
+
+			select {
+			case <-bot.TwitchClient.(*mockIRC).messageChan:
+				t.Errorf("Bot responded, but should fail silently")
+			case <-time.After(3 * time.Second):
+				t.Error("Message handling unsuccessful due to timeout")
+			case <-wait:
+			}
+		})
+	})
+
+	t.Run("HiscoresDown", func(t *testing.T) {
+		bot := NewMockBot()
+		bot.HiscoreAPI = &HiscoreAPI{Client: newUnavailableHiscoreAPIClient()}
+		bot.OutageNotices = NewThrottle(time.Minute)
+
+		testUser := twitch.User{
+			Username:    "testuser",
+			DisplayName: "TestUser",
+		}
+		testMessage := twitch.Message{
+			Text: fmt.Sprintf("!lvl ranged %s", ironmanAccount),
+		}
+
+		expected := fmt.Sprintf(
+			"/me @%s The OSRS hiscores are unavailable right now, try again later",
+			testUser.DisplayName,
+		)
+
+		go bot.HandleMessage("whatever channel doesn't matter", testUser, testMessage)
+
+		select {
+		case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+			if resp != expected {
+				t.Errorf("Said %s, but expected to say %s", resp, expected)
+			}
+		case <-time.After(3 * time.Second):
+			t.Error("Message handling unsuccessful due to timeout")
+		}
+
+		// Only one notice is sent per channel within the window
+		wait := make(chan struct{})
+		go func() {
+			bot.HandleSkillLookup("whatever channel doesn't matter", testUser.DisplayName, "ranged", ironmanAccount)
+			wait <- struct{}{}
+		}()
+
+		select {
+		case <-bot.TwitchClient.(*mockIRC).messageChan:
+			t.Errorf("Bot repeated the outage notice within the window")
+		case <-time.After(3 * time.Second):
+			t.Error("Message handling unsuccessful due to timeout")
+		case <-wait:
+		}
+	})
 }
@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/gempir/go-twitch-irc"
+)
+
+// Permission Level of trust a user needs to run a command, each level including
+// the ones below it
+type Permission int
+
+const (
+	// PermissionEveryone Anyone in chat
+	PermissionEveryone Permission = iota
+	// PermissionSubscriber Subscribers of the channel
+	PermissionSubscriber
+	// PermissionVIP VIPs of the channel
+	PermissionVIP
+	// PermissionModerator Moderators of the channel
+	PermissionModerator
+	// PermissionBroadcaster The owner of the channel
+	PermissionBroadcaster
+)
+
+var (
+	// Permission names concurrent to the Permission constants
+	permissionNames []string = []string{
+		"everyone",
+		"subscriber",
+		"vip",
+		"moderator",
+		"broadcaster",
+	}
+
+	// Badges granting each Permission above PermissionEveryone, checked from the
+	// highest Permission down. Founders are the first subscribers of a channel,
+	// and their badge replaces the subscriber one
+	permissionBadges map[Permission][]string = map[Permission][]string{
+		PermissionBroadcaster: []string{"broadcaster"},
+		PermissionModerator:   []string{"moderator"},
+		PermissionVIP:         []string{"vip"},
+		PermissionSubscriber:  []string{"subscriber", "founder"},
+	}
+)
+
+func (permission Permission) String() string {
+	if permission < PermissionEveryone || int(permission) >= len(permissionNames) {
+		return fmt.Sprintf("Permission(%d)", int(permission))
+	}
+
+	return permissionNames[permission]
+}
+
+// UnknownPermissionError Returned when a name doesn't map to a Permission
+type UnknownPermissionError struct {
+	Name string
+}
+
+func (e *UnknownPermissionError) Error() string {
+	return fmt.Sprintf("Unknown permission %s, expected one of %s", e.Name, strings.Join(permissionNames, ", "))
+}
+
+// ParsePermission Maps a name, as returned by Permission.String, to its Permission
+func ParsePermission(name string) (Permission, error) {
+	for i, permissionName := range permissionNames {
+		if strings.EqualFold(permissionName, strings.TrimSpace(name)) {
+			return Permission(i), nil
+		}
+	}
+
+	return PermissionEveryone, &UnknownPermissionError{name}
+}
+
+// UserPermission Returns the highest Permission the user has in the channel they
+// sent a message in, based on their badges
+func UserPermission(user twitch.User) Permission {
+	for permission := PermissionBroadcaster; permission > PermissionEveryone; permission-- {
+		for _, badge := range permissionBadges[permission] {
+			// The value is the badge's version, which starts at 0 for new
+			// subscribers and founders, so only whether it's there matters
+			if _, ok := user.Badges[badge]; ok {
+				return permission
+			}
+		}
+	}
+
+	return PermissionEveryone
+}
+
+// RequiredPermission Returns the Permission needed to run command in the channel,
+// found by path. The channel's override is used if it has a valid one
+func RequiredPermission(channel Channel, path string, command Command) Permission {
+	if name, ok := channel.Permissions[path]; ok {
+		if permission, err := ParsePermission(name); err == nil {
+			return permission
+		}
+
+		log.Printf("Ignoring invalid permission %s for %s in channel %s", name, path, channel.Name)
+	}
+
+	return command.Permission()
+}
+
+// UnknownCommandError Returned when a path doesn't map to a registered command
+type UnknownCommandError struct {
+	Path string
+}
+
+func (e *UnknownCommandError) Error() string {
+	return fmt.Sprintf("Unknown command %s", e.Path)
+}
+
+// ChangePermissions Updates an existing channel by applying overrides to its
+// Permission overrides. Overrides map the paths of commands to Permission names,
+// where an empty name removes the override so the command's own Permission applies
+func (bot *OziachBot) ChangePermissions(name string, overrides map[string]string) (Channel, error) {
+	channel, err := bot.ChannelDB.GetChannel(name)
+	if err != nil {
+		return Channel{}, err
+	}
+
+	permissions := make(map[string]string, len(channel.Permissions)+len(overrides))
+	for path, permission := range channel.Permissions {
+		permissions[path] = permission
+	}
+
+	for path, permissionName := range overrides {
+		_, resolved, ok := bot.commandRegistry().Resolve(strings.TrimPrefix(path, CommandPrefix))
+		if !ok {
+			return Channel{}, &UnknownCommandError{path}
+		}
+
+		if permissionName == "" {
+			delete(permissions, resolved)
+			continue
+		}
+
+		permission, err := ParsePermission(permissionName)
+		if err != nil {
+			return Channel{}, err
+		}
+		permissions[resolved] = permission.String()
+	}
+
+	builder := expression.NewBuilder().WithUpdate(
+		expression.Set(expression.Name("permissions"), expression.Value(permissions)),
+	)
+
+	log.Printf("Attempting to change permissions of channel %s to %v", name, permissions)
+	return bot.ChannelDB.UpdateChannel(name, builder)
+}
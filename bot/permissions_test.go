@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc"
+)
+
+func TestUserPermission(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Badges   map[string]int
+		Expected Permission
+	}
+
+	testCases := []testCase{
+		testCase{"NoBadges", nil, PermissionEveryone},
+		testCase{"OtherBadges", map[string]int{"premium": 1, "bits": 100}, PermissionEveryone},
+		testCase{"Subscriber", map[string]int{"subscriber": 12}, PermissionSubscriber},
+		testCase{"NewSubscriber", map[string]int{"subscriber": 0}, PermissionSubscriber},
+		testCase{"Founder", map[string]int{"founder": 0}, PermissionSubscriber},
+		testCase{"ActiveFounder", map[string]int{"founder": 1}, PermissionSubscriber},
+		testCase{"VIP", map[string]int{"vip": 1, "subscriber": 3}, PermissionVIP},
+		testCase{"Moderator", map[string]int{"moderator": 1, "vip": 1}, PermissionModerator},
+		testCase{"Broadcaster", map[string]int{"broadcaster": 1, "subscriber": 0}, PermissionBroadcaster},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := UserPermission(twitch.User{Badges: tc.Badges}); actual != tc.Expected {
+				t.Errorf("Expected permission %s, found %s", tc.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestParsePermission(t *testing.T) {
+	for permission := PermissionEveryone; permission <= PermissionBroadcaster; permission++ {
+		parsed, err := ParsePermission(permission.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed != permission {
+			t.Errorf("Parsed %s as %s", permission, parsed)
+		}
+	}
+
+	if parsed, err := ParsePermission("VIP"); err != nil || parsed != PermissionVIP {
+		t.Errorf("Expected VIP to parse in any case, found %s, %v", parsed, err)
+	}
+
+	if _, err := ParsePermission("admin"); err == nil {
+		t.Error("Parsed unknown permission admin")
+	}
+}
+
+func TestRequiredPermission(t *testing.T) {
+	command := &command{name: "alias", permission: PermissionModerator}
+
+	t.Run("Default", func(t *testing.T) {
+		if actual := RequiredPermission(Channel{}, "alias", command); actual != PermissionModerator {
+			t.Errorf("Expected permission moderator, found %s", actual)
+		}
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		channel := Channel{Permissions: map[string]string{"alias": "vip"}}
+
+		if actual := RequiredPermission(channel, "alias", command); actual != PermissionVIP {
+			t.Errorf("Expected permission vip, found %s", actual)
+		}
+	})
+
+	t.Run("InvalidOverride", func(t *testing.T) {
+		channel := Channel{Permissions: map[string]string{"alias": "admin"}}
+
+		if actual := RequiredPermission(channel, "alias", command); actual != PermissionModerator {
+			t.Errorf("Expected permission moderator, found %s", actual)
+		}
+	})
+}
+
+func TestChangePermissions(t *testing.T) {
+	bot := NewMockBot()
+	bot.ChannelDB = newStoredChannelDB(Channel{
+		Name:        "channel",
+		Permissions: map[string]string{"stats": "subscriber"},
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		channel, err := bot.ChangePermissions("channel", map[string]string{
+			"!level":        "VIP",
+			"session start": "moderator",
+			"stats":         "",
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]string{"lvl": "vip", "session start": "moderator"}
+		if !reflect.DeepEqual(channel.Permissions, expected) {
+			t.Errorf("Expected permissions %v, found %v", expected, channel.Permissions)
+		}
+	})
+
+	t.Run("UnknownCommand", func(t *testing.T) {
+		_, err := bot.ChangePermissions("channel", map[string]string{"session stop": "everyone"})
+
+		if _, ok := err.(*UnknownCommandError); !ok {
+			t.Errorf("Expected UnknownCommandError, found %v", err)
+		}
+	})
+
+	t.Run("UnknownPermission", func(t *testing.T) {
+		_, err := bot.ChangePermissions("channel", map[string]string{"lvl": "admin"})
+
+		if _, ok := err.(*UnknownPermissionError); !ok {
+			t.Errorf("Expected UnknownPermissionError, found %v", err)
+		}
+	})
+}
+
+func TestDispatchPermissions(t *testing.T) {
+	bot := NewMockBot()
+	bot.ChannelDB = newStoredChannelDB(Channel{
+		Name:        "channel",
+		Permissions: map[string]string{"open": "everyone", "closed": "subscriber"},
+	})
+
+	runs := make(chan string)
+	record := func(bot *OziachBot, ctx CommandContext) error {
+		runs <- ctx.User.DisplayName
+		return nil
+	}
+
+	bot.Commands, _ = NewCommandRegistry(
+		&command{name: "open", permission: PermissionModerator, handler: record},
+		&command{name: "closed", handler: record},
+		&command{name: "vip", permission: PermissionVIP, handler: record},
+	)
+
+	viewer := twitch.User{DisplayName: "Viewer"}
+	subscriber := twitch.User{DisplayName: "Subscriber", Badges: map[string]int{"subscriber": 1}}
+	vip := twitch.User{DisplayName: "VIP", Badges: map[string]int{"vip": 1}}
+
+	expectRun := func(user twitch.User, text string, expected bool) {
+		t.Helper()
+		bot.Dispatch("channel", user, text)
+
+		select {
+		case <-runs:
+			if !expected {
+				t.Errorf("%s was allowed to run %s", user.DisplayName, text)
+			}
+		case <-time.After(100 * time.Millisecond):
+			if expected {
+				t.Errorf("%s was not allowed to run %s", user.DisplayName, text)
+			}
+		}
+	}
+
+	expectRun(viewer, "!open", true)
+	expectRun(viewer, "!closed", false)
+	expectRun(subscriber, "!closed", true)
+	expectRun(subscriber, "!vip", false)
+	expectRun(vip, "!vip", true)
+	expectRun(vip, "!closed", true)
+}
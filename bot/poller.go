@@ -0,0 +1,251 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/dustin/go-humanize"
+)
+
+var (
+	// totalLevelMilestone Total level announcements are made every multiple of this
+	totalLevelMilestone = 100
+
+	// scoreMilestones Clue scroll and kill count milestones that get announced
+	scoreMilestones = []int{50, 100, 250, 500, 1000, 2500, 5000, 10000}
+)
+
+// Poller Periodically looks up the RSN of every connected channel that opted into
+// announcements, and announces its level ups and milestones, or the loss of its
+// Hardcore status, in the channel
+//
+// Each round of polling is spread evenly over Interval, so that the Hiscore API
+// sees a steady trickle of requests however many channels there are
+type Poller struct {
+	Bot      *OziachBot
+	Interval time.Duration
+
+	// Announcements Limits announcements to one per channel per window. Milestones
+	// reached while a channel is throttled are announced once the window passes.
+	// Every announcement is sent if nil
+	Announcements *Throttle
+
+	mutex    sync.Mutex
+	previous map[string]polledHiscores
+}
+
+// polledHiscores Hiscores of a channel RSN as of the last announcement
+type polledHiscores struct {
+	rsn      string
+	hiscores Hiscores
+}
+
+// NewPoller Returns a Poller polling every channel once per interval, announcing at
+// most once per channel per announceWindow
+func NewPoller(bot *OziachBot, interval, announceWindow time.Duration) *Poller {
+	return &Poller{
+		Bot:           bot,
+		Interval:      interval,
+		Announcements: NewThrottle(announceWindow),
+		previous:      map[string]polledHiscores{},
+	}
+}
+
+// Run Polls every channel until stop is closed
+func (poller *Poller) Run(stop <-chan struct{}) {
+	for poller.Poll(stop) {
+	}
+}
+
+// Poll Polls every channel that opted into announcements once, spread evenly over
+// Interval. Returns false if stop was closed before it finished
+func (poller *Poller) Poll(stop <-chan struct{}) bool {
+	channels, err := poller.Bot.ChannelDB.GetAllChannels()
+
+	if err != nil {
+		log.Println("Could not get channels to poll:", err)
+		channels = []Channel{}
+	}
+
+	polled := []Channel{}
+	for _, channel := range channels {
+		announces := channel.Settings.Announcements || channel.Settings.AnnounceStatusLoss
+		if channel.IsConnected && channel.RSN != "" && announces {
+			polled = append(polled, channel)
+		}
+	}
+
+	if len(polled) == 0 {
+		return wait(stop, poller.Interval)
+	}
+
+	gap := poller.Interval / time.Duration(len(polled))
+	for _, channel := range polled {
+		poller.PollChannel(channel)
+
+		if !wait(stop, gap) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PollChannel Looks up the channel RSN and announces anything it reached since the
+// last announcement. The first lookup of an RSN only records its hiscores
+func (poller *Poller) PollChannel(channel Channel) {
+	hiscores, mode, err := poller.Bot.lookupHiscores(channel.RSN)
+
+	if err != nil {
+		log.Printf("Could not poll %s for channel %s: %s", channel.RSN, channel.Name, err)
+		return
+	}
+
+	poller.checkGameMode(channel, mode)
+
+	if !channel.Settings.Announcements {
+		return
+	}
+
+	poller.mutex.Lock()
+	previous, ok := poller.previous[channel.Name]
+	poller.mutex.Unlock()
+
+	if ok && previous.rsn == channel.RSN {
+		milestones := DetectMilestones(channel.RSN, previous.hiscores, hiscores)
+
+		if len(milestones) == 0 {
+			return
+		}
+
+		// Keep the old hiscores so the milestones are announced after the window
+		if !poller.Announcements.Allow(channel.Name) {
+			return
+		}
+
+		for _, message := range splitMessage("", milestones, " | ", maxSayLength) {
+			poller.Bot.Say(channel.Name, message)
+		}
+	}
+
+	poller.mutex.Lock()
+	poller.previous[channel.Name] = polledHiscores{channel.RSN, hiscores}
+	poller.mutex.Unlock()
+}
+
+// checkGameMode Remembers the GameMode detected for the channel RSN on its record,
+// announcing if it lost its Hardcore status since the last time
+func (poller *Poller) checkGameMode(channel Channel, mode GameMode) {
+	if channel.Mode == mode.Name {
+		return
+	}
+
+	previous, err := GetGameModeFromName(channel.Mode)
+	if err == nil && LostStatus(previous, mode) && channel.Settings.AnnounceStatusLoss {
+		// Keep the old GameMode so the loss is announced after the window
+		if !poller.Announcements.Allow(channel.Name) {
+			return
+		}
+
+		poller.Bot.Say(channel.Name, fmt.Sprintf("%s has lost their %s status", channel.RSN, previous.Name))
+	}
+
+	// Expression builder to set mode
+	builder := expression.NewBuilder().WithUpdate(
+		expression.Set(expression.Name("mode"), expression.Value(mode.Name)),
+	)
+
+	if _, err := poller.Bot.ChannelDB.UpdateChannel(channel.Name, builder); err != nil {
+		log.Printf("Could not remember game mode of channel %s: %s", channel.Name, err)
+	}
+}
+
+// DetectMilestones Returns an announcement for every level up, total level
+// milestone, and clue scroll or kill count milestone between two lookups of a player
+func DetectMilestones(player string, previous, current Hiscores) []string {
+	milestones := []string{}
+
+	for skill := SkillAttack; int(skill) < len(skillNames); skill++ {
+		before, after := previous.skills[skill].Level, current.skills[skill].Level
+
+		switch {
+		case after <= before:
+		case after == 99:
+			milestones = append(milestones, fmt.Sprintf("%s just achieved 99 %s!", player, skillNames[skill]))
+		default:
+			milestones = append(milestones, fmt.Sprintf("%s just reached level %d %s", player, after, skillNames[skill]))
+		}
+	}
+
+	before, after := previous.skills[SkillOverall].Level, current.skills[SkillOverall].Level
+	if after/totalLevelMilestone > before/totalLevelMilestone {
+		milestones = append(milestones, fmt.Sprintf(
+			"%s just reached %s total level",
+			player,
+			humanize.Comma(int64(after/totalLevelMilestone*totalLevelMilestone)),
+		))
+	}
+
+	for clue := range current.clues {
+		if milestone, ok := scoreMilestoneReached(previous.clues[clue], current.clues[clue]); ok {
+			tier := clueNames[clue]
+			if Clue(clue) == ClueOverallClues {
+				tier = "all"
+			}
+
+			milestones = append(milestones, fmt.Sprintf(
+				"%s just completed %s clue scrolls (%s)",
+				player,
+				humanize.Comma(int64(milestone)),
+				tier,
+			))
+		}
+	}
+
+	for _, boss := range bossNames {
+		if milestone, ok := scoreMilestoneReached(previous.bosses[boss], current.bosses[boss]); ok {
+			milestones = append(milestones, fmt.Sprintf(
+				"%s just reached %s %s kill count",
+				player,
+				humanize.Comma(int64(milestone)),
+				boss,
+			))
+		}
+	}
+
+	return milestones
+}
+
+// scoreMilestoneReached Returns the highest of scoreMilestones passed between two
+// lookups of a minigame hiscore. Scores that weren't ranked before are unknown, so
+// they can't have passed a milestone
+func scoreMilestoneReached(previous, current MinigameHiscore) (int, bool) {
+	if previous.Rank == 0 || current.Rank == 0 {
+		return 0, false
+	}
+
+	reached, ok := 0, false
+	for _, milestone := range scoreMilestones {
+		if previous.Score < milestone && current.Score >= milestone {
+			reached, ok = milestone, true
+		}
+	}
+
+	return reached, ok
+}
+
+// wait Waits for the duration, returning false if stop was closed first
+func wait(stop <-chan struct{}, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
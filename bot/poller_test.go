@@ -0,0 +1,246 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubHiscoreAPIClient Answers for every player in the Normal GameMode with the
+// stubbed hiscores, which can be swapped between lookups
+type stubHiscoreAPIClient struct {
+	mutex    sync.Mutex
+	hiscores Hiscores
+}
+
+func (client *stubHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	if mode != GameModeNormal {
+		return "", &HiscoreAPIError{player, mode}
+	}
+
+	return player, nil
+}
+
+func (client *stubHiscoreAPIClient) ParseHiscores(response string) (Hiscores, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	return client.hiscores, nil
+}
+
+func (client *stubHiscoreAPIClient) set(hiscores Hiscores) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	client.hiscores = hiscores
+}
+
+// newMilestoneHiscores Returns Hiscores where every skill has the given level
+func newMilestoneHiscores(level int) Hiscores {
+	hiscores := newHiscores()
+
+	for skill := range hiscores.skills {
+		hiscores.skills[skill] = SkillHiscore{Rank: 1, Level: level, Exp: 1}
+	}
+	hiscores.skills[SkillOverall].Level = level * (len(skillNames) - 1)
+
+	return hiscores
+}
+
+func TestDetectMilestones(t *testing.T) {
+	previous := newMilestoneHiscores(60)
+	previous.skills[SkillSlayer].Level = 98
+	previous.clues[ClueEliteClues] = MinigameHiscore{Rank: 10, Score: 95}
+	previous.bosses["Zulrah"] = MinigameHiscore{Rank: 10, Score: 240}
+
+	current := newMilestoneHiscores(60)
+	current.skills[SkillOverall].Level = previous.skills[SkillOverall].Level + 45
+	current.skills[SkillSlayer].Level = 99
+	current.skills[SkillMagic].Level = 62
+	current.clues[ClueEliteClues] = MinigameHiscore{Rank: 9, Score: 101}
+	current.bosses["Zulrah"] = MinigameHiscore{Rank: 9, Score: 520}
+	current.bosses["Vorkath"] = MinigameHiscore{Rank: 9, Score: 1000}
+
+	expected := []string{
+		"player just reached level 62 Magic",
+		"player just achieved 99 Slayer!",
+		"player just reached 1,400 total level",
+		"player just completed 100 clue scrolls (Elite)",
+		"player just reached 500 Zulrah kill count",
+	}
+
+	actual := DetectMilestones("player", previous, current)
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected milestones %q, got %q", expected, actual)
+	}
+
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Expected milestone %s, got %s", expected[i], actual[i])
+		}
+	}
+}
+
+func TestPoller(t *testing.T) {
+	bot := NewMockBot()
+	client := &stubHiscoreAPIClient{hiscores: newMilestoneHiscores(50)}
+	bot.HiscoreAPI = &HiscoreAPI{Client: client}
+
+	optedIn := Channel{Name: "optedin", IsConnected: true, RSN: "streamer", Settings: ChannelSettings{Announcements: true}}
+	optedOut := Channel{Name: "optedout", IsConnected: true, RSN: "other"}
+	bot.ChannelDB = newStoredChannelDB(optedIn, optedOut)
+
+	poller := NewPoller(&bot, 10*time.Millisecond, time.Hour)
+	messages := bot.TwitchClient.(*mockIRC).messageChan
+
+	expectSilence := func() {
+		done := make(chan bool)
+		go func() { done <- poller.Poll(nil) }()
+
+		select {
+		case message := <-messages:
+			t.Errorf("Expected no announcement, got %s", message)
+		case <-done:
+		}
+	}
+
+	// The first poll only records hiscores
+	expectSilence()
+
+	t.Run("Announce", func(t *testing.T) {
+		leveled := newMilestoneHiscores(50)
+		leveled.skills[SkillAgility].Level = 51
+		client.set(leveled)
+
+		done := make(chan bool)
+		go func() { done <- poller.Poll(nil) }()
+
+		select {
+		case message := <-messages:
+			if expected := "/me streamer just reached level 51 Agility"; message != expected {
+				t.Errorf("Said %s, but expected to say %s", message, expected)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("Announcement timed out")
+		}
+		<-done
+	})
+
+	t.Run("Throttled", func(t *testing.T) {
+		leveled := newMilestoneHiscores(50)
+		leveled.skills[SkillAgility].Level = 52
+		client.set(leveled)
+
+		expectSilence()
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		stop := make(chan struct{})
+		close(stop)
+
+		if poller.Poll(stop) {
+			t.Errorf("Poll finished despite being stopped")
+		}
+	})
+}
+
+func TestPollerStatusLoss(t *testing.T) {
+	bot := NewMockBot()
+	poller := NewPoller(&bot, time.Millisecond, time.Hour)
+	messages := bot.TwitchClient.(*mockIRC).messageChan
+
+	channel := Channel{
+		Name:        "channel",
+		IsConnected: true,
+		RSN:         fallenHardcoreAccount,
+		Mode:        GameModeHardcoreIronman.Name,
+		Settings:    ChannelSettings{AnnounceStatusLoss: true},
+	}
+
+	t.Run("Announce", func(t *testing.T) {
+		go poller.PollChannel(channel)
+
+		select {
+		case message := <-messages:
+			expected := fmt.Sprintf("/me %s has lost their Hardcore Ironman status", fallenHardcoreAccount)
+			if message != expected {
+				t.Errorf("Said %s, but expected to say %s", message, expected)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("Announcement timed out")
+		}
+	})
+
+	t.Run("Throttled", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			poller.PollChannel(channel)
+			close(done)
+		}()
+
+		select {
+		case message := <-messages:
+			t.Errorf("Expected no announcement, got %s", message)
+		case <-done:
+		}
+	})
+
+	t.Run("OptedOut", func(t *testing.T) {
+		channel.Settings.AnnounceStatusLoss = false
+		done := make(chan struct{})
+		go func() {
+			poller.PollChannel(channel)
+			close(done)
+		}()
+
+		select {
+		case message := <-messages:
+			t.Errorf("Expected no announcement, got %s", message)
+		case <-done:
+		}
+	})
+}
+
+func TestPollerStatusLossRememberedGameMode(t *testing.T) {
+	hiscores := newMilestoneHiscores(50)
+	client := &gameModeHiscoreAPIClient{hiscores: map[string]Hiscores{
+		GameModeNormal.urlComponent:          hiscores,
+		GameModeIronman.urlComponent:         hiscores,
+		GameModeHardcoreIronman.urlComponent: hiscores,
+	}}
+
+	bot := NewMockBot()
+	bot.HiscoreAPI = &HiscoreAPI{Client: client, ModeTTL: time.Hour}
+	poller := NewPoller(&bot, time.Millisecond, time.Hour)
+	messages := bot.TwitchClient.(*mockIRC).messageChan
+
+	channel := Channel{
+		Name:        "channel",
+		IsConnected: true,
+		RSN:         "streamer",
+		Settings:    ChannelSettings{AnnounceStatusLoss: true},
+	}
+
+	// The first poll detects and remembers the Hardcore Ironman GameMode
+	poller.PollChannel(channel)
+	channel.Mode = GameModeHardcoreIronman.Name
+
+	// The hardcore scores freeze once the player dies, while they keep training
+	trained := newMilestoneHiscores(50)
+	trained.skills[SkillAgility].Exp++
+	client.set(GameModeNormal, trained)
+	client.set(GameModeIronman, trained)
+
+	go poller.PollChannel(channel)
+
+	select {
+	case message := <-messages:
+		if expected := "/me streamer has lost their Hardcore Ironman status"; message != expected {
+			t.Errorf("Said %s, but expected to say %s", message, expected)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Announcement timed out")
+	}
+}
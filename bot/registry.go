@@ -0,0 +1,605 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gempir/go-twitch-irc"
+)
+
+// CommandPrefix Prefix every command is invoked with in chat
+const CommandPrefix = "!"
+
+// ArgSpec Arguments a command takes, in order: the required Args, then an optional
+// selector, then players
+type ArgSpec struct {
+	// Args Names of the required arguments, one word each unless quoted
+	Args []string
+
+	// Selector Name of an optional argument before the players, which is only
+	// taken if IsSelector accepts it. SelectorDefault is used when it's left out
+	Selector        string
+	IsSelector      func(arg string) bool
+	SelectorDefault string
+
+	// Players Number of players taken after everything else. The last player
+	// takes the rest of the message, so any before it with spaces must be quoted.
+	// If only the first is left out, the channel RSN takes its place
+	Players int
+	// PlayersOptional Runs the command without any players when they're all left
+	// out and the channel has no RSN, instead of ignoring it
+	PlayersOptional bool
+	// NoChannelRSN Never fills in a player with the channel RSN
+	NoChannelRSN bool
+	// WithoutPlayers Runs the command without any players, ignoring the rest of
+	// the message, when it returns true for the parsed Args. Used by invocations
+	// that don't look anyone up, so the channel RSN isn't checked for them
+	WithoutPlayers func(args []string) bool
+}
+
+// CommandContext Invocation of a command, with its arguments parsed by its ArgSpec
+type CommandContext struct {
+	Channel  string
+	User     twitch.User
+	Args     []string
+	Selector string
+	Players  []RSN
+}
+
+// Player Returns the i-th player the command was invoked with
+func (ctx CommandContext) Player(i int) string {
+	return ctx.Players[i].String()
+}
+
+// Command Chat command OziachBot responds to
+type Command interface {
+	// Name Name the command is invoked with, without CommandPrefix
+	Name() string
+	// Aliases Other names the command can be invoked with
+	Aliases() []string
+	// Usage Describes how the command is invoked
+	Usage() string
+	// Permission Permission a user needs to run the command
+	Permission() Permission
+	// Args Arguments the command takes
+	Args() ArgSpec
+	// Run Runs the command for an invocation with valid arguments
+	Run(bot *OziachBot, ctx CommandContext) error
+}
+
+// CommandHandler Function running an invocation of a command
+type CommandHandler func(bot *OziachBot, ctx CommandContext) error
+
+// command Command made of its parts. Subcommands are invoked with their name as
+// the first argument of the command, and take precedence over it
+type command struct {
+	name        string
+	aliases     []string
+	usage       string
+	permission  Permission
+	args        ArgSpec
+	handler     CommandHandler
+	subcommands []Command
+}
+
+func (c *command) Name() string {
+	return c.name
+}
+
+func (c *command) Aliases() []string {
+	return c.aliases
+}
+
+func (c *command) Usage() string {
+	return c.usage
+}
+
+func (c *command) Permission() Permission {
+	return c.permission
+}
+
+func (c *command) Args() ArgSpec {
+	return c.args
+}
+
+func (c *command) Run(bot *OziachBot, ctx CommandContext) error {
+	if c.handler == nil {
+		return nil
+	}
+
+	return c.handler(bot, ctx)
+}
+
+func (c *command) Subcommands() []Command {
+	return c.subcommands
+}
+
+// DuplicateCommandError Returned when registering a command under a name that's
+// already taken
+type DuplicateCommandError struct {
+	Name string
+}
+
+func (e *DuplicateCommandError) Error() string {
+	return fmt.Sprintf("Command %s is already registered", e.Name)
+}
+
+// CommandRegistry Commands OziachBot dispatches messages to, by name and alias
+type CommandRegistry struct {
+	commands []Command
+	names    map[string]Command
+}
+
+// NewCommandRegistry Creates a CommandRegistry holding the given commands
+func NewCommandRegistry(commands ...Command) (*CommandRegistry, error) {
+	registry := &CommandRegistry{names: map[string]Command{}}
+
+	for _, command := range commands {
+		if err := registry.Register(command); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// Register Adds a command to the registry under its name and aliases
+func (registry *CommandRegistry) Register(command Command) error {
+	names := append([]string{command.Name()}, command.Aliases()...)
+
+	for _, name := range names {
+		if _, ok := registry.names[name]; ok {
+			return &DuplicateCommandError{name}
+		}
+	}
+
+	for _, name := range names {
+		registry.names[name] = command
+	}
+	registry.commands = append(registry.commands, command)
+
+	return nil
+}
+
+// Lookup Returns the command invoked by name or alias
+func (registry *CommandRegistry) Lookup(name string) (Command, bool) {
+	command, ok := registry.names[name]
+	return command, ok
+}
+
+// Commands Returns every registered command, in the order they were registered
+func (registry *CommandRegistry) Commands() []Command {
+	return registry.commands
+}
+
+// commandRegistry Returns the registry OziachBot dispatches to, which holds the
+// built-in commands unless it's been given its own
+func (bot *OziachBot) commandRegistry() *CommandRegistry {
+	if bot.Commands != nil {
+		return bot.Commands
+	}
+
+	return builtinCommands
+}
+
+// Dispatch Runs the command invoked by text, if there is one and user may run it.
+// Invocations with arguments that don't fit the command are ignored
+func (bot *OziachBot) Dispatch(channel string, user twitch.User, text string) {
+	if !strings.HasPrefix(text, CommandPrefix) {
+		return
+	}
+
+	name, rest := nextArg(strings.TrimPrefix(text, CommandPrefix))
+	command, ok := bot.commandRegistry().Lookup(name)
+	if !ok {
+		return
+	}
+
+	command, path, rest := resolveSubcommand(command, rest)
+
+	// A channel without a record has no overrides or RSN
+	obChannel, _ := bot.ChannelDB.GetChannel(channel)
+
+	if UserPermission(user) < RequiredPermission(obChannel, path, command) {
+		return
+	}
+
+	ctx, ok, err := bot.parseArgs(obChannel, channel, user, command.Args(), rest)
+	if !ok {
+		return
+	}
+
+	// Invocations on cooldown are ignored, replying to them would only add to
+	// the spam cooldowns are there to stop
+	if !bot.Cooldowns.Allow(path, ctx, obChannel.Settings.Cooldowns[path]) {
+		log.Printf("Command %s%s in channel %s is on cooldown", CommandPrefix, path, channel)
+		return
+	}
+
+	if err != nil {
+		if invalid, ok := err.(*InvalidRSNError); ok {
+			bot.Say(channel, FormatInvalidRSNOutput(user.DisplayName, invalid.Name))
+		}
+		return
+	}
+
+	go func() {
+		if err := command.Run(bot, ctx); err != nil {
+			log.Printf("Command %s%s in channel %s failed: %s", CommandPrefix, path, channel, err)
+		}
+	}()
+}
+
+// Resolve Returns the command or subcommand invoked by path, the words after
+// CommandPrefix that name it, along with its path by name instead of any alias
+func (registry *CommandRegistry) Resolve(path string) (Command, string, bool) {
+	name, rest := nextArg(path)
+	command, ok := registry.Lookup(name)
+	if !ok {
+		return nil, "", false
+	}
+
+	command, resolved, rest := resolveSubcommand(command, rest)
+	return command, resolved, rest == ""
+}
+
+// resolveSubcommand Follows the subcommands of command named by the arguments,
+// returning the innermost one with its path and the arguments left for it
+func resolveSubcommand(command Command, rest string) (Command, string, string) {
+	path := command.Name()
+
+	for {
+		parent, ok := command.(interface{ Subcommands() []Command })
+		if !ok || rest == "" {
+			return command, path, rest
+		}
+
+		name, after := nextArg(rest)
+		found := false
+
+		for _, subcommand := range parent.Subcommands() {
+			if subcommand.Name() == name {
+				command, rest, found = subcommand, after, true
+				path += " " + name
+				break
+			}
+		}
+
+		if !found {
+			return command, path, rest
+		}
+	}
+}
+
+// parseArgs Parses the arguments of an invocation in channel by spec, with the
+// channel's record for its RSN. Returns false if they don't fit spec. A player
+// that isn't a valid RSN still fits, returning InvalidRSNError for the caller to
+// reply with once the invocation is off cooldown
+func (bot *OziachBot) parseArgs(obChannel Channel, channel string, user twitch.User, spec ArgSpec, rest string) (CommandContext, bool, error) {
+	ctx := CommandContext{
+		Channel:  channel,
+		User:     user,
+		Args:     make([]string, len(spec.Args)),
+		Selector: spec.SelectorDefault,
+		Players:  []RSN{},
+	}
+
+	for i := range spec.Args {
+		if rest == "" {
+			return ctx, false, nil
+		}
+		ctx.Args[i], rest = nextArg(rest)
+	}
+
+	if spec.IsSelector != nil && rest != "" {
+		if selector, after := nextArg(rest); spec.IsSelector(selector) {
+			ctx.Selector, rest = selector, after
+		}
+	}
+
+	if spec.Players == 0 {
+		return ctx, rest == "", nil
+	}
+
+	if spec.WithoutPlayers != nil && spec.WithoutPlayers(ctx.Args) {
+		return ctx, true, nil
+	}
+
+	players := []string{}
+	for len(players) < spec.Players-1 && rest != "" {
+		var player string
+		player, rest = nextArg(rest)
+		players = append(players, player)
+	}
+	if rest != "" {
+		players = append(players, rest)
+	}
+
+	// The channel RSN stands in for the first player, so a lone player given to
+	// a command taking two is compared against it
+	if len(players) == spec.Players-1 && obChannel.RSN != "" && !spec.NoChannelRSN {
+		players = append([]string{obChannel.RSN}, players...)
+	}
+
+	if len(players) < spec.Players {
+		return ctx, spec.PlayersOptional && len(players) == 0, nil
+	}
+
+	var invalid error
+	for _, player := range players {
+		// Blank players are ignored like missing ones, without a reply
+		if strings.TrimSpace(player) == "" {
+			return ctx, false, nil
+		}
+
+		rsn, err := ParseRSN(player)
+		if err != nil {
+			// Invalid players keep the name given, so each one has its own
+			// argument cooldown
+			rsn = RSN(strings.TrimSpace(player))
+			if invalid == nil {
+				invalid = err
+			}
+		}
+		ctx.Players = append(ctx.Players, rsn)
+	}
+
+	return ctx, true, invalid
+}
+
+// nextArg Splits the first argument off of text, returning it and the rest
+func nextArg(text string) (string, string) {
+	args := splitArgs(text, 2)
+
+	switch len(args) {
+	case 0:
+		return "", ""
+	case 1:
+		return args[0], ""
+	default:
+		return args[0], args[1]
+	}
+}
+
+// isExpArg Returns true if the first argument is an amount of exp instead of a
+// skill name
+func isExpArg(args []string) bool {
+	_, err := ParseExp(args[0])
+	return err == nil
+}
+
+// builtinCommands Registry of every command OziachBot comes with
+var builtinCommands = mustCommandRegistry(
+	&command{
+		name:    "lvl",
+		aliases: []string{"level"},
+		usage:   "!lvl <skill> [player] or !lvl <exp>",
+		args: ArgSpec{
+			Args:            []string{"skill"},
+			Players:         1,
+			PlayersOptional: true,
+			WithoutPlayers:  isExpArg,
+		},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			// A number instead of a skill name asks for the level at that much
+			// exp, which doesn't need a hiscore lookup
+			if exp, err := ParseExp(ctx.Args[0]); err == nil {
+				return bot.HandleLevelCalculation(ctx.Channel, ctx.User.DisplayName, exp)
+			}
+
+			if len(ctx.Players) == 0 {
+				return nil
+			}
+
+			return bot.HandleSkillLookup(ctx.Channel, ctx.User.DisplayName, ctx.Args[0], ctx.Player(0))
+		},
+	},
+	&command{
+		name:    "total",
+		aliases: []string{"overall"},
+		usage:   "!total [player]",
+		args:    ArgSpec{Players: 1},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleSkillLookup(ctx.Channel, ctx.User.DisplayName, "overall", ctx.Player(0))
+		},
+	},
+	&command{
+		name:  "stats",
+		usage: "!stats [player]",
+		args:  ArgSpec{Players: 1},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleStatsLookup(ctx.Channel, ctx.User.DisplayName, ctx.Player(0))
+		},
+	},
+	&command{
+		name:    "cb",
+		aliases: []string{"combat"},
+		usage:   "!cb [player]",
+		args:    ArgSpec{Players: 1},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleCombatLookup(ctx.Channel, ctx.User.DisplayName, ctx.Player(0))
+		},
+	},
+	&command{
+		name:    "clues",
+		aliases: []string{"clue"},
+		usage:   "!clues [tier] [player]",
+		args: ArgSpec{
+			Selector: "tier",
+			IsSelector: func(arg string) bool {
+				_, ok := clueAliases[strings.ToLower(arg)]
+				return ok
+			},
+			SelectorDefault: "all",
+			Players:         1,
+		},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleClueLookup(ctx.Channel, ctx.User.DisplayName, ctx.Selector, ctx.Player(0))
+		},
+	},
+	&command{
+		name:  "lms",
+		usage: "!lms [player]",
+		args:  ArgSpec{Players: 1},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleLMSLookup(ctx.Channel, ctx.User.DisplayName, ctx.Player(0))
+		},
+	},
+	&command{
+		name:    "bh",
+		aliases: []string{"bounty"},
+		usage:   "!bh [hunter|rogue] [player]",
+		args: ArgSpec{
+			Selector: "score",
+			IsSelector: func(arg string) bool {
+				_, ok := bountyHunterAliases[strings.ToLower(arg)]
+				return ok
+			},
+			Players: 1,
+		},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleBountyHunterLookup(ctx.Channel, ctx.User.DisplayName, ctx.Selector, ctx.Player(0))
+		},
+	},
+	&command{
+		name:  "compare",
+		usage: "!compare <skill|all> <player> [player]",
+		args:  ArgSpec{Args: []string{"skill"}, Players: 2},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleCompareLookup(ctx.Channel, ctx.User.DisplayName, ctx.Args[0], ctx.Player(0), ctx.Player(1))
+		},
+	},
+	&command{
+		name:  "gained",
+		usage: "!gained [day|week|month|stream] [player]",
+		args: ArgSpec{
+			Selector: "period",
+			IsSelector: func(arg string) bool {
+				_, ok := gainedPeriods[strings.ToLower(arg)]
+				return ok
+			},
+			SelectorDefault: "day",
+			Players:         1,
+		},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleGainedLookup(ctx.Channel, ctx.User.DisplayName, ctx.Selector, ctx.Player(0))
+		},
+	},
+	&command{
+		name:  "session",
+		usage: "!session or !session start",
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleSessionLookup(ctx.Channel, ctx.User.DisplayName)
+		},
+		subcommands: []Command{
+			&command{
+				name:       "start",
+				usage:      "!session start",
+				permission: PermissionBroadcaster,
+				handler: func(bot *OziachBot, ctx CommandContext) error {
+					return bot.HandleSessionStart(ctx.Channel, ctx.User.DisplayName)
+				},
+			},
+		},
+	},
+	&command{
+		name:       "alias",
+		usage:      "!alias add <alias> <skill> or !alias remove <alias>",
+		permission: PermissionModerator,
+		subcommands: []Command{
+			&command{
+				name:       "add",
+				usage:      "!alias add <alias> <skill>",
+				permission: PermissionModerator,
+				args:       ArgSpec{Args: []string{"alias", "skill"}},
+				handler: func(bot *OziachBot, ctx CommandContext) error {
+					return bot.HandleAliasAdd(ctx.Channel, ctx.User.DisplayName, ctx.Args[0], ctx.Args[1])
+				},
+			},
+			&command{
+				name:       "remove",
+				usage:      "!alias remove <alias>",
+				permission: PermissionModerator,
+				args:       ArgSpec{Args: []string{"alias"}},
+				handler: func(bot *OziachBot, ctx CommandContext) error {
+					return bot.HandleAliasRemove(ctx.Channel, ctx.User.DisplayName, ctx.Args[0])
+				},
+			},
+		},
+	},
+	&command{
+		name:  "rsn",
+		usage: "!rsn",
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleRSNLookup(ctx.Channel, ctx.User.DisplayName)
+		},
+	},
+	&command{
+		name:       "setrsn",
+		usage:      "!setrsn <name>",
+		permission: PermissionModerator,
+		args:       ArgSpec{Players: 1, NoChannelRSN: true},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleSetRSN(ctx.Channel, ctx.User.DisplayName, ctx.Player(0))
+		},
+	},
+	&command{
+		name:       "ob",
+		usage:      "!ob settings or !ob settings <setting> <on|off>",
+		permission: PermissionModerator,
+		subcommands: []Command{
+			&command{
+				name:       "settings",
+				usage:      "!ob settings or !ob settings <setting> <on|off>",
+				permission: PermissionModerator,
+				handler: func(bot *OziachBot, ctx CommandContext) error {
+					return bot.HandleSettingsLookup(ctx.Channel, ctx.User.DisplayName)
+				},
+			},
+		},
+	},
+	&command{
+		name:    "xp",
+		aliases: []string{"exp"},
+		usage:   "!xp <level>",
+		args:    ArgSpec{Args: []string{"level"}},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			level, err := strconv.Atoi(ctx.Args[0])
+			if err != nil {
+				return nil
+			}
+
+			return bot.HandleExpCalculation(ctx.Channel, ctx.User.DisplayName, level)
+		},
+	},
+	&command{
+		name:    "kc",
+		aliases: []string{"killcount"},
+		usage:   "!kc <boss> [player]",
+		args:    ArgSpec{Args: []string{"boss"}, Players: 1},
+		handler: func(bot *OziachBot, ctx CommandContext) error {
+			return bot.HandleKillCountLookup(ctx.Channel, ctx.User.DisplayName, ctx.Args[0], ctx.Player(0))
+		},
+	},
+)
+
+// Setting changes go through the registry to validate the cooldowns stored with
+// them, so their commands are attached once builtinCommands is initialized
+func init() {
+	obCommand, _ := builtinCommands.Lookup("ob")
+	settingsCommand, _, _ := resolveSubcommand(obCommand, "settings")
+	settingsCommand.(*command).subcommands = settingCommands()
+}
+
+// mustCommandRegistry Creates a CommandRegistry of commands that are known to have
+// distinct names, panicking if they don't
+func mustCommandRegistry(commands ...Command) *CommandRegistry {
+	registry, err := NewCommandRegistry(commands...)
+	if err != nil {
+		panic(err)
+	}
+
+	return registry
+}
@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc"
+)
+
+func TestCommandRegistry(t *testing.T) {
+	registry, err := NewCommandRegistry(
+		&command{name: "lvl", aliases: []string{"level"}},
+		&command{name: "stats"},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if command, ok := registry.Lookup("level"); !ok || command.Name() != "lvl" {
+		t.Errorf("Expected level to look up lvl, found %v", command)
+	}
+
+	if _, ok := registry.Lookup("!lvl"); ok {
+		t.Error("Expected lookups to be made without the command prefix")
+	}
+
+	err = registry.Register(&command{name: "statistics", aliases: []string{"stats"}})
+	if _, ok := err.(*DuplicateCommandError); !ok {
+		t.Errorf("Expected DuplicateCommandError, found %v", err)
+	}
+
+	if _, ok := registry.Lookup("statistics"); ok {
+		t.Error("Expected a duplicate command to not be registered under any name")
+	}
+
+	if len(registry.Commands()) != 2 {
+		t.Errorf("Expected 2 registered commands, found %d", len(registry.Commands()))
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	bot := NewMockBot()
+	bot.ChannelDB = newStoredChannelDB(Channel{Name: "channel", RSN: "Lynx Titan"})
+
+	contexts := make(chan CommandContext)
+	record := func(bot *OziachBot, ctx CommandContext) error {
+		contexts <- ctx
+		return nil
+	}
+
+	bot.Commands, _ = NewCommandRegistry(
+		&command{
+			name: "one",
+			args: ArgSpec{
+				Args:            []string{"arg"},
+				Selector:        "selector",
+				IsSelector:      func(arg string) bool { return arg == "picked" },
+				SelectorDefault: "default",
+				Players:         1,
+			},
+			handler: record,
+		},
+		&command{
+			name:    "two",
+			args:    ArgSpec{Players: 2},
+			handler: record,
+		},
+		&command{
+			name:    "none",
+			args:    ArgSpec{Args: []string{"arg"}},
+			handler: record,
+			subcommands: []Command{
+				&command{name: "mod", permission: PermissionModerator, handler: record},
+			},
+		},
+	)
+
+	viewer := twitch.User{DisplayName: "Viewer"}
+	moderator := twitch.User{DisplayName: "Moderator", Badges: map[string]int{"moderator": 1}}
+
+	expectContext := func(channel string, user twitch.User, text string, expected CommandContext) {
+		t.Helper()
+		bot.Dispatch(channel, user, text)
+
+		expected.Channel = channel
+		expected.User = user
+
+		select {
+		case ctx := <-contexts:
+			if !reflect.DeepEqual(ctx, expected) {
+				t.Errorf("Dispatched %s with %+v, expected %+v", text, ctx, expected)
+			}
+		case <-time.After(3 * time.Second):
+			t.Errorf("Command %s was not run", text)
+		}
+	}
+
+	expectIgnored := func(channel string, user twitch.User, text string) {
+		t.Helper()
+		bot.Dispatch(channel, user, text)
+
+		select {
+		case ctx := <-contexts:
+			t.Errorf("Expected %s to be ignored, but it ran with %+v", text, ctx)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	t.Run("Selector", func(t *testing.T) {
+		expectContext("channel", viewer, "!one arg picked Zezima", CommandContext{
+			Args:     []string{"arg"},
+			Selector: "picked",
+			Players:  []RSN{"Zezima"},
+		})
+		expectContext("channel", viewer, "!one arg Zezima", CommandContext{
+			Args:     []string{"arg"},
+			Selector: "default",
+			Players:  []RSN{"Zezima"},
+		})
+	})
+
+	t.Run("ChannelRSN", func(t *testing.T) {
+		expectContext("channel", viewer, "!one arg", CommandContext{
+			Args:     []string{"arg"},
+			Selector: "default",
+			Players:  []RSN{"Lynx Titan"},
+		})
+		expectContext("channel", viewer, "!two Zezima", CommandContext{
+			Args:    []string{},
+			Players: []RSN{"Lynx Titan", "Zezima"},
+		})
+		expectIgnored("other channel", viewer, "!two Zezima")
+	})
+
+	t.Run("QuotedPlayers", func(t *testing.T) {
+		expectContext("channel", viewer, `!two "Lynx Titan" B0aty`, CommandContext{
+			Args:    []string{},
+			Players: []RSN{"Lynx Titan", "B0aty"},
+		})
+	})
+
+	t.Run("Subcommand", func(t *testing.T) {
+		expectContext("channel", moderator, "!none mod", CommandContext{
+			Args:    []string{},
+			Players: []RSN{},
+		})
+		expectIgnored("channel", viewer, "!none mod")
+		expectContext("channel", viewer, "!none other", CommandContext{
+			Args:    []string{"other"},
+			Players: []RSN{},
+		})
+	})
+
+	t.Run("Ignored", func(t *testing.T) {
+		expectIgnored("channel", viewer, "one arg Zezima")
+		expectIgnored("channel", viewer, "!unknown arg Zezima")
+		expectIgnored("channel", viewer, "!none")
+		expectIgnored("channel", viewer, "!none too many")
+	})
+}
+
+func TestDispatchExpWithInvalidChannelRSN(t *testing.T) {
+	bot := NewMockBot()
+
+	// Channels can have RSNs stored before they were validated
+	bot.ChannelDB = newStoredChannelDB(Channel{Name: "channel", RSN: "Not.Valid"})
+	viewer := twitch.User{DisplayName: "Viewer"}
+
+	go bot.Dispatch("channel", viewer, "!lvl 1000000")
+
+	select {
+	case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+		if expected := "/me " + FormatLevelCalculationOutput("Viewer", 1000000); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Message handling unsuccessful due to timeout")
+	}
+}
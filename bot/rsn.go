@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxRSNLength Longest RSN Jagex allows
+const MaxRSNLength = 12
+
+// rsnSeparators Characters the hiscores treat the same as a space in an RSN
+var rsnSeparators = strings.NewReplacer("_", " ", "-", " ", "\u00a0", " ")
+
+// RSN RuneScape name of a player, normalized so that the same name written with
+// different separators or spacing is equal. Case is kept so replies show the name
+// as it was written. The hiscores ignore case, and so does everything keyed by
+// player, like the cache, remembered GameModes and snapshots
+type RSN string
+
+// InvalidRSNError Returned when a name breaks the rules Jagex has for RSNs
+type InvalidRSNError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InvalidRSNError) Error() string {
+	return fmt.Sprintf("%s is not a valid RSN: %s", e.Name, e.Reason)
+}
+
+// ParseRSN Parses a name as written in chat into an RSN. The name may be wrapped
+// in double quotes, and underscores, hyphens and non-breaking spaces are read as
+// spaces like the hiscores read them. What's left can only be letters, numbers and
+// single spaces, up to MaxRSNLength characters
+func ParseRSN(name string) (RSN, error) {
+	normalized := unquote(strings.TrimSpace(name))
+	normalized = strings.Join(strings.Fields(rsnSeparators.Replace(normalized)), " ")
+
+	if normalized == "" {
+		return "", &InvalidRSNError{name, "name is empty"}
+	}
+
+	for _, r := range normalized {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ') {
+			return "", &InvalidRSNError{name, fmt.Sprintf("%q is not allowed", r)}
+		}
+	}
+
+	if len(normalized) > MaxRSNLength {
+		return "", &InvalidRSNError{name, fmt.Sprintf("longer than %d characters", MaxRSNLength)}
+	}
+
+	return RSN(normalized), nil
+}
+
+// String Returns the RSN as it's displayed in game
+func (rsn RSN) String() string {
+	return string(rsn)
+}
+
+// splitArgs Splits a message on spaces like strings.SplitN, with the last of the n
+// parts holding the rest of the message. Text in double quotes is never split, so
+// it can hold a multi-word argument before the last, and the quotes are removed
+// from arguments wrapped in them. Runs of spaces separate arguments the same as one
+func splitArgs(text string, n int) []string {
+	args := []string{}
+	start := 0
+	quoted := false
+
+	for i, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted && i == start:
+			start = i + 1
+		case r == ' ' && !quoted && len(args) < n-1:
+			args = append(args, unquote(text[start:i]))
+			start = i + 1
+		}
+	}
+
+	if start < len(text) {
+		args = append(args, unquote(text[start:]))
+	}
+
+	return args
+}
+
+// unquote Removes the double quotes wrapping text, if there are any
+func unquote(text string) string {
+	if len(text) >= 2 && strings.HasPrefix(text, `"`) && strings.HasSuffix(text, `"`) {
+		return text[1 : len(text)-1]
+	}
+
+	return text
+}
+
+// FormatInvalidRSNOutput Formats the reply to a player argument that isn't an RSN
+func FormatInvalidRSNOutput(user, player string) string {
+	return fmt.Sprintf("@%s %s is not a valid RSN", user, strings.TrimSpace(player))
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRSN(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Input    string
+		Expected RSN
+	}
+
+	testCases := []testCase{
+		testCase{"Plain", "Zezima", "Zezima"},
+		testCase{"Space", "Lynx Titan", "Lynx Titan"},
+		testCase{"Quoted", `"Lynx Titan"`, "Lynx Titan"},
+		testCase{"Underscore", "Lynx_Titan", "Lynx Titan"},
+		testCase{"Hyphen", "Lynx-Titan", "Lynx Titan"},
+		testCase{"NonBreakingSpace", "Lynx\u00a0Titan", "Lynx Titan"},
+		testCase{"ExtraSpaces", "  Lynx   Titan ", "Lynx Titan"},
+		testCase{"MaxLength", "abcdefghijkl", "abcdefghijkl"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			rsn, err := ParseRSN(tc.Input)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rsn != tc.Expected {
+				t.Errorf("Parsed %q as %q, expected %q", tc.Input, rsn, tc.Expected)
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		for _, name := range []string{"", `""`, "_-_", "abcdefghijklm", "Lynx.Titan", "Zézima"} {
+			if rsn, err := ParseRSN(name); err == nil {
+				t.Errorf("Parsed invalid RSN %q as %q", name, rsn)
+			} else if _, ok := err.(*InvalidRSNError); !ok {
+				t.Errorf("Expected InvalidRSNError for %q, found %v", name, err)
+			}
+		}
+	})
+}
+
+func TestSplitArgs(t *testing.T) {
+	type testCase struct {
+		Text     string
+		N        int
+		Expected []string
+	}
+
+	testCases := []testCase{
+		testCase{"!lvl ranged Lynx Titan", 3, []string{"!lvl", "ranged", "Lynx Titan"}},
+		testCase{"!lvl  ranged   Lynx Titan", 3, []string{"!lvl", "ranged", "Lynx Titan"}},
+		testCase{"!lvl ranged", 3, []string{"!lvl", "ranged"}},
+		testCase{`!compare atk "Lynx Titan" Zezima`, 4, []string{"!compare", "atk", "Lynx Titan", "Zezima"}},
+		testCase{`!stats "Lynx Titan"`, 2, []string{"!stats", "Lynx Titan"}},
+		testCase{`!kc "Theatre of Blood" Zezima`, 3, []string{"!kc", "Theatre of Blood", "Zezima"}},
+	}
+
+	for _, tc := range testCases {
+		if actual := splitArgs(tc.Text, tc.N); !reflect.DeepEqual(actual, tc.Expected) {
+			t.Errorf("Split %q into %q, expected %q", tc.Text, actual, tc.Expected)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	// maxListedKills Number of bosses listed in a !session reply
+	maxListedKills = 3
+
+	// joinSessionGap Time between starting the sessions of the channels joined on
+	// startup, so their lookups don't all reach the Hiscore API at once
+	joinSessionGap = 2 * time.Second
+)
+
+// Session Stream session of a channel, stored on its Channel record so that it
+// survives OziachBot restarting
+type Session struct {
+	Start time.Time `json:"start" dynamodbav:"start,unixtime"`
+	// Baseline Snapshot of the channel RSN when the session started
+	Baseline Snapshot `json:"baseline"`
+}
+
+// RSNNotSetError Returned when an operation requires a channel to have an RSN,
+// but it doesn't
+type RSNNotSetError struct {
+	Channel string
+}
+
+func (e RSNNotSetError) Error() string {
+	return fmt.Sprintf("Channel %s has no RSN set", e.Channel)
+}
+
+// BaselineLookupError Returned when the hiscores a session starts from can't be
+// looked up
+type BaselineLookupError struct {
+	Player string
+	Err    error
+}
+
+func (e *BaselineLookupError) Error() string {
+	return fmt.Sprintf("Could not look up session baseline of %s: %s", e.Player, e.Err)
+}
+
+// StartSession Updates an existing channel by starting a new session from the
+// current hiscores of its RSN
+func (bot *OziachBot) StartSession(name string) (Channel, error) {
+	channel, err := bot.ChannelDB.GetChannel(name)
+
+	if err != nil {
+		return channel, err
+	}
+
+	if channel.RSN == "" {
+		return channel, RSNNotSetError{name}
+	}
+
+	hiscores, mode, err := bot.lookupHiscores(channel.RSN)
+
+	if err != nil {
+		return channel, &BaselineLookupError{channel.RSN, err}
+	}
+
+	now := time.Now()
+	session := Session{
+		Start:    now,
+		Baseline: NewSnapshot(channel.RSN, mode, hiscores, now),
+	}
+
+	// Expression builder to set session
+	builder := expression.NewBuilder().WithUpdate(
+		expression.Set(expression.Name("session"), expression.Value(session)),
+	)
+
+	log.Printf("Attempting to start session of channel %s", name)
+	return bot.ChannelDB.UpdateChannel(name, builder)
+}
+
+// startJoinSession Starts a session for a channel OziachBot joined. A session that
+// was already running is kept if resume is set, as it is after a restart
+func (bot *OziachBot) startJoinSession(channel Channel, resume bool) {
+	if channel.RSN == "" || (resume && channel.Session != nil) {
+		return
+	}
+
+	if _, err := bot.StartSession(channel.Name); err != nil {
+		log.Printf("Could not start session of channel %s: %s", channel.Name, err)
+	}
+}
+
+// startJoinSessions Starts sessions for the channels OziachBot joined on startup
+// one after another, waiting gap between the ones that need a lookup
+func (bot *OziachBot) startJoinSessions(channels []Channel, gap time.Duration) {
+	started := 0
+
+	for _, channel := range channels {
+		if channel.RSN == "" || channel.Session != nil {
+			continue
+		}
+
+		if started > 0 {
+			time.Sleep(gap)
+		}
+		started++
+
+		bot.startJoinSession(channel, true)
+	}
+}
+
+// HandleSessionStart Starts a new session for the channel and confirms it
+func (bot *OziachBot) HandleSessionStart(channel, user string) error {
+	obChannel, err := bot.StartSession(channel)
+
+	switch e := err.(type) {
+	case nil:
+		bot.Say(channel, fmt.Sprintf("@%s Session started for %s", user, obChannel.RSN))
+	case RSNNotSetError:
+		bot.Say(channel, fmt.Sprintf("@%s Set an RSN for this channel to track sessions", user))
+	case ChannelNotFoundError:
+		// Channels without a record can't have sessions
+	case *BaselineLookupError:
+		bot.sayLookupFailure(channel, user, e.Player, e.Err)
+	default:
+		log.Printf("Could not start session of channel %s: %s", channel, err)
+		bot.Say(channel, fmt.Sprintf("@%s Could not start a session, try again later", user))
+	}
+
+	return err
+}
+
+// HandleSessionLookup Sends the exp, levels and kill count the channel RSN gained
+// since the session started
+func (bot *OziachBot) HandleSessionLookup(channel, user string) error {
+	obChannel, err := bot.ChannelDB.GetChannel(channel)
+
+	if err != nil {
+		return err
+	}
+
+	if obChannel.Session == nil || obChannel.RSN == "" {
+		bot.Say(channel, fmt.Sprintf("@%s No session running, the broadcaster can start one with !session start", user))
+		return nil
+	}
+
+	hiscores, mode, err := bot.lookupHiscores(obChannel.RSN)
+	if err != nil {
+		bot.sayLookupFailure(channel, user, obChannel.RSN, err)
+		return err
+	}
+
+	current := NewSnapshot(obChannel.RSN, mode, hiscores, time.Now())
+	overall, gains := CompareSnapshots(obChannel.Session.Baseline, current)
+	kills := CompareKillCounts(obChannel.Session.Baseline, current)
+
+	bot.Say(channel, FormatSessionOutput(user, obChannel.RSN, obChannel.Session.Start, overall, gains, kills))
+	return nil
+}
+
+// FormatSessionOutput Formats the gains of a session, with the skills gaining the
+// most exp and the bosses gaining the most kills
+func FormatSessionOutput(user, player string, start time.Time, overall SkillGain, gains []SkillGain, kills []BossGain) string {
+	output := fmt.Sprintf(
+		"@%s - %s | Session started %s: %s exp, %s levels",
+		user,
+		player,
+		humanize.Time(start),
+		humanize.Comma(int64(overall.Exp)),
+		humanize.Comma(int64(overall.Levels)),
+	)
+
+	output += formatSkillGains(gains)
+
+	if len(kills) > maxListedKills {
+		kills = kills[:maxListedKills]
+	}
+
+	for _, kill := range kills {
+		output += fmt.Sprintf(" | %s +%s kc", kill.Boss, humanize.Comma(int64(kill.Kills)))
+	}
+
+	return output
+}
@@ -0,0 +1,210 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc"
+)
+
+func TestSessions(t *testing.T) {
+	bot := NewMockBot()
+	channelDB := newStoredChannelDB(Channel{Name: "channel", IsConnected: true, RSN: ironmanAccount})
+	bot.ChannelDB = channelDB
+
+	broadcaster := twitch.User{
+		Username:    "broadcaster",
+		DisplayName: "Broadcaster",
+		Badges:      map[string]int{"broadcaster": 1},
+	}
+	viewer := twitch.User{
+		Username:    "viewer",
+		DisplayName: "Viewer",
+	}
+
+	say := func(user twitch.User, text string) string {
+		go bot.HandleMessage("channel", user, twitch.Message{Text: text})
+
+		select {
+		case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+			return resp
+		case <-time.After(3 * time.Second):
+			t.Fatal("Message handling unsuccessful due to timeout")
+			return ""
+		}
+	}
+
+	t.Run("NoSession", func(t *testing.T) {
+		expected := "/me @Viewer No session running, the broadcaster can start one with !session start"
+
+		if resp := say(viewer, "!session"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("ViewerStart", func(t *testing.T) {
+		wait := make(chan struct{})
+		go func() {
+			bot.HandleMessage("channel", viewer, twitch.Message{Text: "!session start"})
+			wait <- struct{}{}
+		}()
+
+		select {
+		case <-bot.TwitchClient.(*mockIRC).messageChan:
+			t.Errorf("Bot responded, but only the broadcaster can start sessions")
+		case <-time.After(3 * time.Second):
+			t.Error("Message handling unsuccessful due to timeout")
+		case <-wait:
+		}
+	})
+
+	t.Run("Start", func(t *testing.T) {
+		expected := fmt.Sprintf("/me @Broadcaster Session started for %s", ironmanAccount)
+
+		if resp := say(broadcaster, "!session start"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+
+		if session := channelDB.channel("channel").Session; session == nil || len(session.Baseline.Skills) != len(skillNames) {
+			t.Errorf("Expected session baseline to be stored, got %+v", session)
+		}
+	})
+
+	t.Run("Report", func(t *testing.T) {
+		expected := fmt.Sprintf(
+			"/me @Viewer - %s | Session started now: 0 exp, 0 levels | No exp gained",
+			ironmanAccount,
+		)
+
+		if resp := say(viewer, "!session"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("ResumeOnRestart", func(t *testing.T) {
+		// Started earlier, so a replacement can't be mistaken for it
+		channel := channelDB.channel("channel")
+		channel.Session.Start = channel.Session.Start.Add(-time.Hour)
+		channelDB.put(channel)
+
+		bot.startJoinSession(channel, true)
+
+		if actual := channelDB.channel("channel").Session; !reflect.DeepEqual(actual, channel.Session) {
+			t.Errorf("Running session was replaced on restart")
+		}
+	})
+
+	t.Run("StartLookupFailed", func(t *testing.T) {
+		channel := channelDB.channel("channel")
+		channelDB.put(Channel{Name: "channel", IsConnected: true, RSN: notAnAccount})
+		defer channelDB.put(channel)
+
+		expected := fmt.Sprintf("/me @Broadcaster Could not find player %s", notAnAccount)
+		if resp := say(broadcaster, "!session start"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("StartUpdateFailed", func(t *testing.T) {
+		channelDB.failUpdates(errors.New("update failed"))
+		defer channelDB.failUpdates(nil)
+
+		expected := "/me @Broadcaster Could not start a session, try again later"
+		if resp := say(broadcaster, "!session start"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("RSNChange", func(t *testing.T) {
+		if err := bot.ChangeRSN("channel", normalAccount); err != nil {
+			t.Fatal(err)
+		}
+
+		// The session of the old RSN can't be compared against the new one
+		expected := "/me @Viewer No session running, the broadcaster can start one with !session start"
+		if resp := say(viewer, "!session"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("NoRSN", func(t *testing.T) {
+		channel := channelDB.channel("channel")
+		channel.RSN = ""
+		channelDB.put(channel)
+
+		if _, err := bot.StartSession("channel"); err == nil {
+			t.Errorf("Expected RSNNotSetError, got success")
+		}
+	})
+}
+
+func TestFormatSessionOutput(t *testing.T) {
+	kills := []BossGain{{"Zulrah", 12}, {"Vorkath", 4}, {"Cerberus", 2}, {"Kraken", 1}}
+	gains := []SkillGain{{SkillRanged, 120000, 1}}
+	actual := FormatSessionOutput("user", "player", time.Now(), SkillGain{SkillOverall, 150000, 1}, gains, kills)
+
+	expected := "@user - player | Session started now: 150,000 exp, 1 levels | Ranged +120,000 exp (+1)" +
+		" | Zulrah +12 kc | Vorkath +4 kc | Cerberus +2 kc"
+
+	if actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+
+	if strings.Contains(actual, "Kraken") {
+		t.Errorf("Expected at most %d bosses, got %s", maxListedKills, actual)
+	}
+}
+
+// timingHiscoreAPIClient Wraps a HiscoreAPIClient, recording when each Normal
+// GameMode request is made through it
+type timingHiscoreAPIClient struct {
+	HiscoreAPIClient
+
+	mutex sync.Mutex
+	times []time.Time
+}
+
+func (client *timingHiscoreAPIClient) GetAPIResponse(ctx context.Context, player string, mode GameMode) (string, error) {
+	if mode == GameModeNormal {
+		client.mutex.Lock()
+		client.times = append(client.times, time.Now())
+		client.mutex.Unlock()
+	}
+
+	return client.HiscoreAPIClient.GetAPIResponse(ctx, player, mode)
+}
+
+func TestStartJoinSessions(t *testing.T) {
+	bot := NewMockBot()
+	client := &timingHiscoreAPIClient{HiscoreAPIClient: &mockHiscoreAPIClient{}}
+	bot.HiscoreAPI = &HiscoreAPI{Client: client}
+
+	channels := []Channel{
+		Channel{Name: "first", IsConnected: true, RSN: normalAccount},
+		Channel{Name: "norsn", IsConnected: true},
+		Channel{Name: "running", IsConnected: true, RSN: normalAccount, Session: &Session{}},
+		Channel{Name: "second", IsConnected: true, RSN: ironmanAccount},
+		Channel{Name: "third", IsConnected: true, RSN: hardcoreAccount},
+	}
+	bot.ChannelDB = newStoredChannelDB(channels...)
+
+	gap := 50 * time.Millisecond
+	bot.startJoinSessions(channels, gap)
+
+	// Only the channels with an RSN and no running session are looked up
+	if len(client.times) != 3 {
+		t.Fatalf("Expected 3 lookups, got %d", len(client.times))
+	}
+
+	for i := 1; i < len(client.times); i++ {
+		if elapsed := client.times[i].Sub(client.times[i-1]); elapsed < gap {
+			t.Errorf("Expected lookups at least %s apart, got %s", gap, elapsed)
+		}
+	}
+}
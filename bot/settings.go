@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// settingToggle Channel setting that can be turned on and off in chat, named by
+// its JSON name
+type settingToggle struct {
+	name  string
+	field func(settings *ChannelSettings) *bool
+}
+
+var (
+	// Settings that can be toggled in chat, in the order they're listed
+	settingToggles []settingToggle = []settingToggle{
+		settingToggle{"virtualLevels", func(s *ChannelSettings) *bool { return &s.VirtualLevels }},
+		settingToggle{"expToNextLevel", func(s *ChannelSettings) *bool { return &s.ExpToNextLevel }},
+		settingToggle{"percentToMax", func(s *ChannelSettings) *bool { return &s.PercentToMax }},
+		settingToggle{"announcements", func(s *ChannelSettings) *bool { return &s.Announcements }},
+		settingToggle{"announceStatusLoss", func(s *ChannelSettings) *bool { return &s.AnnounceStatusLoss }},
+	}
+
+	// Words turning a setting on or off in chat
+	toggleValues map[string]bool = map[string]bool{
+		"on":      true,
+		"off":     false,
+		"true":    true,
+		"false":   false,
+		"enable":  true,
+		"disable": false,
+	}
+)
+
+// HandleRSNLookup Replies with the RSN of the channel
+func (bot *OziachBot) HandleRSNLookup(channel, user string) error {
+	obChannel, err := bot.ChannelDB.GetChannel(channel)
+	if err != nil {
+		log.Printf("Could not look up RSN of channel %s: %s", channel, err)
+		return err
+	}
+
+	if obChannel.RSN == "" {
+		bot.Say(channel, fmt.Sprintf("@%s This channel has no RSN set, mods can set one with !setrsn <name>", user))
+		return nil
+	}
+
+	bot.Say(channel, fmt.Sprintf("@%s This channel's RSN is %s", user, obChannel.RSN))
+	return nil
+}
+
+// HandleSetRSN Changes the RSN of the channel, replying with the RSN it was set to
+func (bot *OziachBot) HandleSetRSN(channel, user, rsn string) error {
+	// Dispatch already replied to RSNs that aren't valid, so any error is the
+	// channel failing to update
+	if err := bot.ChangeRSN(channel, rsn); err != nil {
+		log.Printf("Could not change RSN of channel %s: %s", channel, err)
+		bot.Say(channel, fmt.Sprintf("@%s Could not set RSN to %s, try again later", user, rsn))
+		return err
+	}
+
+	bot.Say(channel, fmt.Sprintf("@%s RSN set to %s", user, rsn))
+	return nil
+}
+
+// HandleSettingsLookup Replies with whether each setting is on in the channel
+func (bot *OziachBot) HandleSettingsLookup(channel, user string) error {
+	obChannel, err := bot.ChannelDB.GetChannel(channel)
+	if err != nil {
+		log.Printf("Could not look up settings of channel %s: %s", channel, err)
+		return err
+	}
+
+	bot.Say(channel, FormatSettingsOutput(user, obChannel.Settings))
+	return nil
+}
+
+// FormatSettingsOutput Formats the settings that can be toggled in chat
+func FormatSettingsOutput(user string, settings ChannelSettings) string {
+	parts := make([]string, len(settingToggles))
+
+	for i, toggle := range settingToggles {
+		parts[i] = fmt.Sprintf("%s: %s", toggle.name, onOff(*toggle.field(&settings)))
+	}
+
+	return fmt.Sprintf("@%s Settings | %s", user, strings.Join(parts, " | "))
+}
+
+// HandleSettingChange Turns a setting of the channel on or off, as given by value
+func (bot *OziachBot) HandleSettingChange(channel, user string, toggle settingToggle, value string) error {
+	on, ok := toggleValues[strings.ToLower(value)]
+	if !ok {
+		bot.Say(channel, fmt.Sprintf("@%s Usage: !ob settings %s <on|off>", user, toggle.name))
+		return nil
+	}
+
+	if _, err := bot.changeSetting(channel, toggle, on); err != nil {
+		log.Printf("Could not change setting %s of channel %s: %s", toggle.name, channel, err)
+		bot.Say(channel, fmt.Sprintf("@%s Could not turn %s %s, try again later", user, toggle.name, onOff(on)))
+		return err
+	}
+
+	bot.Say(channel, fmt.Sprintf("@%s %s turned %s", user, toggle.name, onOff(on)))
+	return nil
+}
+
+// changeSetting Updates an existing channel by turning a single setting on or off.
+// Only the setting is updated, so settings changed at the same time are kept
+func (bot *OziachBot) changeSetting(name string, toggle settingToggle, on bool) (Channel, error) {
+	builder := expression.NewBuilder().WithUpdate(
+		expression.Set(expression.Name("settings."+toggle.name), expression.Value(on)),
+	)
+
+	log.Printf("Attempting to turn setting %s of channel %s %s", toggle.name, name, onOff(on))
+	return bot.ChannelDB.UpdateChannel(name, builder)
+}
+
+// onOff Formats whether a setting is on
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+
+	return "off"
+}
+
+// settingCommands Returns the subcommands of !ob settings turning each setting on
+// or off
+func settingCommands() []Command {
+	commands := make([]Command, len(settingToggles))
+
+	for i, toggle := range settingToggles {
+		toggle := toggle
+		commands[i] = &command{
+			name:       toggle.name,
+			usage:      fmt.Sprintf("!ob settings %s <on|off>", toggle.name),
+			permission: PermissionModerator,
+			args:       ArgSpec{Args: []string{"on|off"}},
+			handler: func(bot *OziachBot, ctx CommandContext) error {
+				return bot.HandleSettingChange(ctx.Channel, ctx.User.DisplayName, toggle, ctx.Args[0])
+			},
+		}
+	}
+
+	return commands
+}
@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc"
+)
+
+func TestChannelConfiguration(t *testing.T) {
+	bot := NewMockBot()
+	// The cooldown of a command that's since been removed doesn't block changes
+	channelDB := newStoredChannelDB(Channel{
+		Name:        "channel",
+		IsConnected: true,
+		Settings:    ChannelSettings{Cooldowns: map[string]Cooldown{"removed": Cooldown{User: 10}}},
+	})
+	bot.ChannelDB = channelDB
+
+	broadcaster := twitch.User{
+		Username:    "broadcaster",
+		DisplayName: "Broadcaster",
+		Badges:      map[string]int{"broadcaster": 1},
+	}
+	viewer := twitch.User{
+		Username:    "viewer",
+		DisplayName: "Viewer",
+	}
+
+	say := func(user twitch.User, text string) string {
+		go bot.HandleMessage("channel", user, twitch.Message{Text: text})
+
+		select {
+		case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+			return resp
+		case <-time.After(3 * time.Second):
+			t.Fatal("Message handling unsuccessful due to timeout")
+			return ""
+		}
+	}
+
+	expectSilence := func(user twitch.User, text string) {
+		bot.HandleMessage("channel", user, twitch.Message{Text: text})
+
+		select {
+		case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+			t.Errorf("Bot responded to %s with %s, but only mods can run it", text, resp)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	t.Run("NoRSN", func(t *testing.T) {
+		expected := "/me @Viewer This channel has no RSN set, mods can set one with !setrsn <name>"
+
+		if resp := say(viewer, "!rsn"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("ViewerSetRSN", func(t *testing.T) {
+		expectSilence(viewer, "!setrsn Zezima")
+	})
+
+	t.Run("SetRSN", func(t *testing.T) {
+		expected := "/me @Broadcaster RSN set to Lynx Titan"
+
+		if resp := say(broadcaster, "!setrsn Lynx_Titan"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+
+		expected = "/me @Viewer This channel's RSN is Lynx Titan"
+
+		if resp := say(viewer, "!rsn"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("SetInvalidRSN", func(t *testing.T) {
+		expected := "/me @Broadcaster Not.An.RSN is not a valid RSN"
+
+		if resp := say(broadcaster, "!setrsn Not.An.RSN"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("SetRSNFailed", func(t *testing.T) {
+		channelDB.failUpdates(errors.New("update failed"))
+		defer channelDB.failUpdates(nil)
+
+		expected := "/me @Broadcaster Could not set RSN to Zezima, try again later"
+		if resp := say(broadcaster, "!setrsn Zezima"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("ViewerSettings", func(t *testing.T) {
+		expectSilence(viewer, "!ob settings")
+		expectSilence(viewer, "!ob settings virtualLevels on")
+	})
+
+	t.Run("Settings", func(t *testing.T) {
+		expected := "/me @Broadcaster virtualLevels turned on"
+
+		if resp := say(broadcaster, "!ob settings virtualLevels enable"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+
+		expected = "/me @Broadcaster Settings | virtualLevels: on | expToNextLevel: off | percentToMax: off | announcements: off | announceStatusLoss: off"
+
+		if resp := say(broadcaster, "!ob settings"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("SettingFailed", func(t *testing.T) {
+		channelDB.failUpdates(errors.New("update failed"))
+		defer channelDB.failUpdates(nil)
+
+		expected := "/me @Broadcaster Could not turn announcements on, try again later"
+		if resp := say(broadcaster, "!ob settings announcements on"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("SettingsUsage", func(t *testing.T) {
+		expected := "/me @Broadcaster Usage: !ob settings announcements <on|off>"
+
+		if resp := say(broadcaster, "!ob settings announcements maybe"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+}
+
+func TestChangeSettingConcurrently(t *testing.T) {
+	bot := NewMockBot()
+	channelDB := newStoredChannelDB(Channel{
+		Name:     "channel",
+		Settings: ChannelSettings{Cooldowns: map[string]Cooldown{"lvl": Cooldown{User: 10}}},
+	})
+	bot.ChannelDB = channelDB
+
+	var wg sync.WaitGroup
+	for _, toggle := range settingToggles {
+		wg.Add(1)
+		go func(toggle settingToggle) {
+			defer wg.Done()
+
+			if _, err := bot.changeSetting("channel", toggle, true); err != nil {
+				t.Error(err)
+			}
+		}(toggle)
+	}
+	wg.Wait()
+
+	// Every setting is turned on and the cooldowns are kept, however the
+	// updates interleave
+	expected := ChannelSettings{
+		VirtualLevels:      true,
+		ExpToNextLevel:     true,
+		PercentToMax:       true,
+		Announcements:      true,
+		AnnounceStatusLoss: true,
+		Cooldowns:          map[string]Cooldown{"lvl": Cooldown{User: 10}},
+	}
+	if actual := channelDB.channel("channel").Settings; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected settings %+v, found %+v", expected, actual)
+	}
+}
@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// minFuzzyLength Shortest name matched by prefix or edit distance. Anything
+	// shorter has too many skills it could be
+	minFuzzyLength = 3
+
+	// shortNameLength Names up to this length are corrected by one edit at most,
+	// longer names by two. Any looser and most short words are close to some skill
+	shortNameLength = 7
+)
+
+// UnknownSkillError Returned when a name doesn't map to a skill. Suggestions holds
+// the official names of the skills it's close to, if any
+type UnknownSkillError struct {
+	Name        string
+	Suggestions []string
+}
+
+func (e *UnknownSkillError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("Could not map %s to skill", e.Name)
+	}
+
+	return fmt.Sprintf("Could not map %s to skill, did you mean %s?", e.Name, joinOr(e.Suggestions))
+}
+
+// MatchSkill Maps a name to a Skill through SkillAliases. Names that aren't an
+// alias are matched against the aliases they prefix, or failing that the aliases
+// closest to them by edit distance. If those all belong to one skill, it's the
+// match, otherwise UnknownSkillError suggests each of them
+func MatchSkill(name string) (Skill, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	if skill, ok := SkillAliases[name]; ok {
+		return skill, nil
+	}
+
+	candidates := matchSkillAliases(name)
+
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	suggestions := make([]string, len(candidates))
+	for i, skill := range candidates {
+		suggestions[i] = skillNames[skill]
+	}
+
+	return 0, &UnknownSkillError{name, suggestions}
+}
+
+// MatchSkillWithAliases Maps a name to a Skill, trying aliases, which map to the
+// official name of a skill, before MatchSkill
+func MatchSkillWithAliases(name string, aliases map[string]string) (Skill, error) {
+	if skillName, ok := aliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		name = skillName
+	}
+
+	return MatchSkill(name)
+}
+
+// matchSkillAliases Returns the distinct skills of the aliases name prefixes, or
+// of the aliases closest to it within the allowed edit distance, in skill order
+func matchSkillAliases(name string) []Skill {
+	if len(name) < minFuzzyLength {
+		return []Skill{}
+	}
+
+	maxDistance := 2
+	if len(name) <= shortNameLength {
+		maxDistance = 1
+	}
+
+	prefixed := map[Skill]struct{}{}
+	closest := map[Skill]struct{}{}
+	closestDistance := maxDistance + 1
+
+	for alias, skill := range SkillAliases {
+		if strings.HasPrefix(alias, name) {
+			prefixed[skill] = struct{}{}
+		}
+
+		distance := levenshtein(name, alias)
+		switch {
+		case distance < closestDistance:
+			closest = map[Skill]struct{}{skill: struct{}{}}
+			closestDistance = distance
+		case distance == closestDistance:
+			closest[skill] = struct{}{}
+		}
+	}
+
+	matches := prefixed
+	if len(matches) == 0 {
+		matches = closest
+	}
+
+	skills := make([]Skill, 0, len(matches))
+	for skill := range matches {
+		skills = append(skills, skill)
+	}
+
+	sort.Slice(skills, func(i, j int) bool {
+		return skills[i] < skills[j]
+	})
+
+	return skills
+}
+
+// levenshtein Returns the edit distance between a and b: the fewest insertions,
+// deletions and substitutions of a single character turning one into the other
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	// Only the previous row of the distance matrix is needed for the next one
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(br); j++ {
+			substitution := previous[j-1]
+			if ar[i-1] != br[j-1] {
+				substitution++
+			}
+
+			current[j] = minInt(substitution, previous[j]+1, current[j-1]+1)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(br)]
+}
+
+// minInt Returns the smallest of the given ints
+func minInt(first int, rest ...int) int {
+	min := first
+	for _, n := range rest {
+		if n < min {
+			min = n
+		}
+	}
+
+	return min
+}
+
+// joinOr Joins words into a list ending in "or", like "Attack, Agility or Magic"
+func joinOr(words []string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+
+	return strings.Join(words[:len(words)-1], ", ") + " or " + words[len(words)-1]
+}
+
+// matchSkill Matches skillName for a reply to user, trying the aliases of the
+// channel first, and tells them which skills they may have meant if it isn't one.
+// Names nothing is close to are ignored
+func (bot *OziachBot) matchSkill(channel, user, skillName string, aliases map[string]string) (Skill, error) {
+	skill, err := MatchSkillWithAliases(skillName, aliases)
+
+	if unknown, ok := err.(*UnknownSkillError); ok && len(unknown.Suggestions) > 0 {
+		bot.Say(channel, FormatUnknownSkillOutput(user, skillName, unknown.Suggestions))
+	}
+
+	return skill, err
+}
+
+// FormatUnknownSkillOutput Formats the reply to a skill name close to several skills
+func FormatUnknownSkillOutput(user, skillName string, suggestions []string) string {
+	return fmt.Sprintf("@%s Unknown skill %s, did you mean %s?", user, skillName, joinOr(suggestions))
+}
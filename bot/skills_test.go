@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchSkill(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Input    string
+		Expected Skill
+	}
+
+	testCases := []testCase{
+		testCase{"Alias", "wc", SkillWoodcutting},
+		testCase{"MixedCase", "Woodcutting", SkillWoodcutting},
+		testCase{"Prefix", "constr", SkillConstruction},
+		testCase{"PrefixOfSeveralAliases", "ran", SkillRanged},
+		testCase{"MissingLetter", "woodcuting", SkillWoodcutting},
+		testCase{"SwappedLetters", "strenght", SkillStrength},
+		testCase{"WrongLetter", "fiahing", SkillFishing},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			skill, err := MatchSkill(tc.Input)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if skill != tc.Expected {
+				t.Errorf("Matched %s to %s, expected %s", tc.Input, skillNames[skill], skillNames[tc.Expected])
+			}
+		})
+	}
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		t.Parallel()
+		_, err := MatchSkill("fiem")
+		expected := []string{"Fishing", "Firemaking", "Farming"}
+
+		unknown, ok := err.(*UnknownSkillError)
+		if !ok {
+			t.Fatalf("Expected UnknownSkillError, found %v", err)
+		}
+
+		if !reflect.DeepEqual(unknown.Suggestions, expected) {
+			t.Errorf("Suggested %v, expected %v", unknown.Suggestions, expected)
+		}
+	})
+
+	t.Run("NotASkill", func(t *testing.T) {
+		t.Parallel()
+
+		for _, name := range []string{"sailing", "mi", ""} {
+			_, err := MatchSkill(name)
+
+			unknown, ok := err.(*UnknownSkillError)
+			if !ok {
+				t.Fatalf("Expected UnknownSkillError for %s, found %v", name, err)
+			}
+
+			if len(unknown.Suggestions) != 0 {
+				t.Errorf("Expected no suggestions for %s, found %v", name, unknown.Suggestions)
+			}
+		}
+	})
+}
+
+func TestLevenshtein(t *testing.T) {
+	type testCase struct {
+		A, B     string
+		Expected int
+	}
+
+	testCases := []testCase{
+		testCase{"", "", 0},
+		testCase{"", "abc", 3},
+		testCase{"abc", "abc", 0},
+		testCase{"kitten", "sitting", 3},
+		testCase{"woodcuting", "woodcutting", 1},
+	}
+
+	for _, tc := range testCases {
+		if actual := levenshtein(tc.A, tc.B); actual != tc.Expected {
+			t.Errorf("Distance between %q and %q was %d, expected %d", tc.A, tc.B, actual, tc.Expected)
+		}
+	}
+}
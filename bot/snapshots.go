@@ -0,0 +1,348 @@
+package bot
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+var (
+	// SnapshotTableName Name of the table holding Snapshot records in DynamoDB
+	SnapshotTableName string = "ob-snapshots"
+
+	// SnapshotRetention Duration snapshots are kept for, long enough to cover the
+	// longest !gained period
+	SnapshotRetention time.Duration = 35 * 24 * time.Hour
+)
+
+// Snapshot DynamoDB schema for the skills of a player at a point in time
+type Snapshot struct {
+	// Player Lowercase name of the player, since names are case insensitive
+	Player    string         `json:"player"`
+	Timestamp time.Time      `json:"timestamp" dynamodbav:"timestamp,unixtime"`
+	Mode      string         `json:"mode"`
+	Skills    []SkillHiscore `json:"skills"`
+	// Bosses Kill count of every boss the player is ranked in
+	Bosses map[string]int `json:"bosses,omitempty"`
+
+	// Expires Time after which DynamoDB deletes the record
+	Expires time.Time `json:"expires" dynamodbav:"expires,unixtime"`
+}
+
+// SkillGain Exp and levels gained in a skill between two snapshots
+type SkillGain struct {
+	Skill  Skill
+	Exp    int
+	Levels int
+}
+
+// BossGain Kills gained on a boss between two snapshots
+type BossGain struct {
+	Boss  string
+	Kills int
+}
+
+// SnapshotStore Interface for storing and querying Snapshots
+type SnapshotStore interface {
+	AddSnapshot(snapshot Snapshot) error
+	// GetSnapshots Returns the snapshots of the player taken at or after since,
+	// oldest first
+	GetSnapshots(player string, since time.Time) ([]Snapshot, error)
+}
+
+// NewSnapshot Returns a Snapshot of the player's skills taken at the given time
+func NewSnapshot(player string, mode GameMode, hiscores Hiscores, at time.Time) Snapshot {
+	skills := make([]SkillHiscore, len(hiscores.skills))
+	copy(skills, hiscores.skills)
+
+	bosses := map[string]int{}
+	for name, boss := range hiscores.bosses {
+		if boss.Rank > 0 {
+			bosses[name] = boss.Score
+		}
+	}
+
+	return Snapshot{
+		Player:    strings.ToLower(player),
+		Timestamp: at,
+		Mode:      mode.Name,
+		Skills:    skills,
+		Bosses:    bosses,
+		Expires:   at.Add(SnapshotRetention),
+	}
+}
+
+// CompareSnapshots Returns the overall gain between two snapshots, along with the
+// gain in every skill that gained exp, most exp first
+func CompareSnapshots(from, to Snapshot) (SkillGain, []SkillGain) {
+	gains := []SkillGain{}
+	var overall SkillGain
+
+	for i := range to.Skills {
+		if i >= len(from.Skills) {
+			break
+		}
+
+		gain := SkillGain{
+			Skill:  Skill(i),
+			Exp:    to.Skills[i].Exp - from.Skills[i].Exp,
+			Levels: to.Skills[i].Level - from.Skills[i].Level,
+		}
+
+		if gain.Skill == SkillOverall {
+			overall = gain
+		} else if gain.Exp > 0 {
+			gains = append(gains, gain)
+		}
+	}
+
+	sort.SliceStable(gains, func(i, j int) bool {
+		return gains[i].Exp > gains[j].Exp
+	})
+
+	return overall, gains
+}
+
+// CompareKillCounts Returns the kills gained on every boss between two snapshots,
+// most kills first. Bosses that weren't ranked in from are left out, since their
+// kill count before ranking is unknown
+func CompareKillCounts(from, to Snapshot) []BossGain {
+	gains := []BossGain{}
+
+	for _, name := range bossNames {
+		before, ok := from.Bosses[name]
+		if !ok {
+			continue
+		}
+
+		if kills := to.Bosses[name] - before; kills > 0 {
+			gains = append(gains, BossGain{name, kills})
+		}
+	}
+
+	sort.SliceStable(gains, func(i, j int) bool {
+		return gains[i].Kills > gains[j].Kills
+	})
+
+	return gains
+}
+
+// InMemorySnapshotStore Implementation of SnapshotStore that keeps snapshots in
+// memory, dropping them after SnapshotRetention
+type InMemorySnapshotStore struct {
+	mutex     sync.Mutex
+	snapshots map[string][]Snapshot
+}
+
+// NewInMemorySnapshotStore Returns an empty InMemorySnapshotStore
+func NewInMemorySnapshotStore() *InMemorySnapshotStore {
+	return &InMemorySnapshotStore{
+		snapshots: map[string][]Snapshot{},
+	}
+}
+
+// AddSnapshot Stores the snapshot, dropping the player's expired snapshots
+func (store *InMemorySnapshotStore) AddSnapshot(snapshot Snapshot) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	key := strings.ToLower(snapshot.Player)
+	snapshots := append(store.snapshots[key], snapshot)
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
+	})
+
+	expired := 0
+	for expired < len(snapshots) && snapshots[expired].Expires.Before(time.Now()) {
+		expired++
+	}
+
+	store.snapshots[key] = snapshots[expired:]
+	return nil
+}
+
+// GetSnapshots Returns the snapshots of the player taken at or after since, oldest
+// first
+func (store *InMemorySnapshotStore) GetSnapshots(player string, since time.Time) ([]Snapshot, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	out := []Snapshot{}
+	for _, snapshot := range store.snapshots[strings.ToLower(player)] {
+		if !snapshot.Timestamp.Before(since) {
+			out = append(out, snapshot)
+		}
+	}
+
+	return out, nil
+}
+
+// DynamoDBSnapshotStore Implementation of SnapshotStore that uses a DynamoDB client
+// to access the database. The table is keyed by player and timestamp, and expires
+// records by the expires attribute
+type DynamoDBSnapshotStore struct {
+	Client *dynamodb.DynamoDB
+}
+
+// AddSnapshot Puts the snapshot into the snapshot table
+func (db *DynamoDBSnapshotStore) AddSnapshot(snapshot Snapshot) error {
+	snapshot.Player = strings.ToLower(snapshot.Player)
+	marshalledSnapshot, err := dynamodbattribute.MarshalMap(snapshot)
+
+	if err != nil {
+		return err
+	}
+
+	putItemInput := &dynamodb.PutItemInput{}
+	putItemInput.SetTableName(SnapshotTableName)
+	putItemInput.SetItem(marshalledSnapshot)
+	_, err = db.Client.PutItem(putItemInput)
+
+	return err
+}
+
+// GetSnapshots Queries the snapshot table for the snapshots of the player taken at
+// or after since, oldest first
+func (db *DynamoDBSnapshotStore) GetSnapshots(player string, since time.Time) ([]Snapshot, error) {
+	keyCondition := expression.Key("player").Equal(expression.Value(strings.ToLower(player))).And(
+		expression.Key("timestamp").GreaterThanEqual(expression.Value(since.Unix())),
+	)
+	expression, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+
+	if err != nil {
+		return []Snapshot{}, err
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expression.Names(),
+		ExpressionAttributeValues: expression.Values(),
+		KeyConditionExpression:    expression.KeyCondition(),
+	}
+	queryInput.SetTableName(SnapshotTableName)
+
+	out := []Snapshot{}
+	for {
+		result, err := db.Client.Query(queryInput)
+		if err != nil {
+			return out, err
+		}
+
+		for _, item := range result.Items {
+			snapshot := Snapshot{}
+			if err := dynamodbattribute.UnmarshalMap(item, &snapshot); err != nil {
+				return out, err
+			}
+
+			out = append(out, snapshot)
+		}
+
+		// Results are paginated past 1MB
+		if len(result.LastEvaluatedKey) == 0 {
+			return out, nil
+		}
+
+		queryInput.SetExclusiveStartKey(result.LastEvaluatedKey)
+	}
+}
+
+// recordSnapshot Stores a snapshot of the player's hiscores from a lookup. Only one
+// snapshot is kept per player within the RecentSnapshots window
+func (bot *OziachBot) recordSnapshot(player string, mode GameMode, hiscores Hiscores) {
+	if bot.Snapshots == nil || !bot.RecentSnapshots.Allow(strings.ToLower(player)) {
+		return
+	}
+
+	if err := bot.Snapshots.AddSnapshot(NewSnapshot(player, mode, hiscores, time.Now())); err != nil {
+		log.Printf("Could not record snapshot of %s: %s", player, err)
+	}
+}
+
+// RecordChannelSnapshots Stores a snapshot of the RSN of every connected channel
+func (bot *OziachBot) RecordChannelSnapshots() error {
+	channels, err := bot.ChannelDB.GetAllChannels()
+
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		if !channel.IsConnected || channel.RSN == "" {
+			continue
+		}
+
+		hiscores, mode, err := bot.HiscoreAPI.LookupHiscores(channel.RSN)
+		if err != nil {
+			log.Printf("Could not look up %s for a snapshot: %s", channel.RSN, err)
+			continue
+		}
+
+		if err := bot.Snapshots.AddSnapshot(NewSnapshot(channel.RSN, mode, hiscores, time.Now())); err != nil {
+			log.Printf("Could not record snapshot of %s: %s", channel.RSN, err)
+		}
+	}
+
+	return nil
+}
+
+// ScheduleSnapshots Calls RecordChannelSnapshots right away, then every interval
+// until stop is closed, so there's a snapshot to compare against soon after startup
+func (bot *OziachBot) ScheduleSnapshots(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := bot.RecordChannelSnapshots(); err != nil {
+			log.Println("Could not record channel snapshots:", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// JoinTimes Times OziachBot joined each channel, used as the start of a stream
+type JoinTimes struct {
+	mutex sync.Mutex
+	times map[string]time.Time
+}
+
+// NewJoinTimes Returns an empty JoinTimes
+func NewJoinTimes() *JoinTimes {
+	return &JoinTimes{
+		times: map[string]time.Time{},
+	}
+}
+
+// Set Records the time OziachBot joined the channel. Does nothing on a nil JoinTimes
+func (joinTimes *JoinTimes) Set(channel string, at time.Time) {
+	if joinTimes == nil {
+		return
+	}
+
+	joinTimes.mutex.Lock()
+	defer joinTimes.mutex.Unlock()
+
+	joinTimes.times[strings.ToLower(channel)] = at
+}
+
+// Get Returns the time OziachBot joined the channel, if it's known
+func (joinTimes *JoinTimes) Get(channel string) (time.Time, bool) {
+	if joinTimes == nil {
+		return time.Time{}, false
+	}
+
+	joinTimes.mutex.Lock()
+	defer joinTimes.mutex.Unlock()
+
+	at, ok := joinTimes.times[strings.ToLower(channel)]
+	return at, ok
+}
@@ -0,0 +1,214 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// newSkillSnapshot Returns a snapshot at the given time where every skill has the
+// given exp, and Ranged has rangedExp
+func newSkillSnapshot(player string, at time.Time, exp, rangedExp int) Snapshot {
+	hiscores := newHiscores()
+	for skill := range hiscores.skills {
+		hiscores.skills[skill] = SkillHiscore{Rank: 1, Level: 50, Exp: exp}
+	}
+	hiscores.skills[SkillRanged] = SkillHiscore{Rank: 1, Level: 50, Exp: rangedExp}
+
+	return NewSnapshot(player, GameModeNormal, hiscores, at)
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	now := time.Now()
+	from := newSkillSnapshot("player", now.Add(-time.Hour), 1000, 1000)
+	to := newSkillSnapshot("player", now, 1000, 5000)
+	to.Skills[SkillOverall] = SkillHiscore{Rank: 1, Level: 51, Exp: 5000}
+	to.Skills[SkillRanged].Level = 51
+	to.Skills[SkillMagic].Exp = 2000
+
+	overall, gains := CompareSnapshots(from, to)
+
+	if expected := (SkillGain{SkillOverall, 4000, 1}); overall != expected {
+		t.Errorf("Expected overall gain %+v, got %+v", expected, overall)
+	}
+
+	expected := []SkillGain{{SkillRanged, 4000, 1}, {SkillMagic, 1000, 0}}
+	if !reflect.DeepEqual(gains, expected) {
+		t.Errorf("Expected gains %+v, got %+v", expected, gains)
+	}
+}
+
+func TestCompareKillCounts(t *testing.T) {
+	from := Snapshot{Bosses: map[string]int{"Zulrah": 100, "Vorkath": 10, "Kraken": 50}}
+	to := Snapshot{Bosses: map[string]int{"Zulrah": 105, "Vorkath": 30, "Kraken": 50, "Cerberus": 60}}
+
+	expected := []BossGain{{"Vorkath", 20}, {"Zulrah", 5}}
+	if actual := CompareKillCounts(from, to); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected kill gains %+v, got %+v", expected, actual)
+	}
+}
+
+func TestInMemorySnapshotStore(t *testing.T) {
+	store := NewInMemorySnapshotStore()
+	now := time.Now()
+
+	expired := newSkillSnapshot("Player", now.Add(-SnapshotRetention-time.Hour), 0, 0)
+	old := newSkillSnapshot("Player", now.Add(-48*time.Hour), 1000, 1000)
+	recent := newSkillSnapshot("Player", now.Add(-time.Hour), 2000, 2000)
+
+	for _, snapshot := range []Snapshot{recent, expired, old} {
+		if err := store.AddSnapshot(snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("All", func(t *testing.T) {
+		snapshots, _ := store.GetSnapshots("PLAYER", time.Time{})
+
+		if !reflect.DeepEqual(snapshots, []Snapshot{old, recent}) {
+			t.Errorf("Expected the unexpired snapshots oldest first, got %+v", snapshots)
+		}
+	})
+
+	t.Run("Since", func(t *testing.T) {
+		snapshots, _ := store.GetSnapshots("player", now.Add(-24*time.Hour))
+
+		if !reflect.DeepEqual(snapshots, []Snapshot{recent}) {
+			t.Errorf("Expected only the recent snapshot, got %+v", snapshots)
+		}
+	})
+
+	t.Run("OtherPlayer", func(t *testing.T) {
+		if snapshots, _ := store.GetSnapshots("other", time.Time{}); len(snapshots) != 0 {
+			t.Errorf("Expected no snapshots, got %+v", snapshots)
+		}
+	})
+}
+
+func TestScheduleSnapshots(t *testing.T) {
+	bot := NewMockBot()
+	store := NewInMemorySnapshotStore()
+	bot.Snapshots = store
+	bot.ChannelDB = newStoredChannelDB(Channel{Name: "channel", IsConnected: true, RSN: normalAccount})
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		bot.ScheduleSnapshots(time.Hour, stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	// The first snapshot is recorded on startup instead of after the interval
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if snapshots, _ := store.GetSnapshots(normalAccount, time.Time{}); len(snapshots) > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Errorf("Expected a snapshot of %s on startup", normalAccount)
+}
+
+func TestSnapshotMarshal(t *testing.T) {
+	snapshot := newSkillSnapshot("player", time.Unix(1577836800, 0), 1000, 2000)
+	item, err := dynamodbattribute.MarshalMap(snapshot)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The sort key and TTL attribute have to be numbers for DynamoDB
+	for _, key := range []string{"timestamp", "expires"} {
+		if item[key] == nil || item[key].N == nil {
+			t.Errorf("Expected %s to be marshalled as a number, got %v", key, item[key])
+		}
+	}
+
+	unmarshalled := Snapshot{}
+	if err := dynamodbattribute.UnmarshalMap(item, &unmarshalled); err != nil {
+		t.Fatal(err)
+	}
+
+	if !unmarshalled.Timestamp.Equal(snapshot.Timestamp) || !reflect.DeepEqual(unmarshalled.Skills, snapshot.Skills) {
+		t.Errorf("Expected %+v, got %+v", snapshot, unmarshalled)
+	}
+}
+
+func TestJoinTimes(t *testing.T) {
+	var nilJoinTimes *JoinTimes
+	nilJoinTimes.Set("channel1", time.Now())
+	if _, ok := nilJoinTimes.Get("channel1"); ok {
+		t.Errorf("Nil JoinTimes returned a join time")
+	}
+
+	joinTimes := NewJoinTimes()
+	now := time.Now()
+	joinTimes.Set("Channel1", now)
+
+	if at, ok := joinTimes.Get("channel1"); !ok || !at.Equal(now) {
+		t.Errorf("Expected join time %v, got %v", now, at)
+	}
+}
+
+func TestHandleGainedLookup(t *testing.T) {
+	bot := NewMockBot()
+	bot.Snapshots = NewInMemorySnapshotStore()
+	bot.RecentSnapshots = NewThrottle(time.Hour)
+	bot.JoinTimes = NewJoinTimes()
+	channel := "channel"
+
+	say := func(period string) string {
+		go bot.HandleGainedLookup(channel, "user", period, ironmanAccount)
+
+		select {
+		case resp := <-bot.TwitchClient.(*mockIRC).messageChan:
+			return resp
+		case <-time.After(3 * time.Second):
+			t.Fatal("Message handling unsuccessful due to timeout")
+			return ""
+		}
+	}
+
+	t.Run("Untracked", func(t *testing.T) {
+		expected := "/me @user " + ironmanAccount + " isn't tracked yet, gains will be counted from now on"
+
+		if resp := say("week"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("Tracked", func(t *testing.T) {
+		// The untracked lookup recorded a snapshot, so nothing was gained since
+		expected := "/me @user - " + ironmanAccount + " | Gained in the past week: 0 exp, 0 levels | No exp gained"
+
+		if resp := say("week"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("Gained", func(t *testing.T) {
+		baseline := newSkillSnapshot(ironmanAccount, time.Now().Add(-72*time.Hour), 0, 0)
+		bot.Snapshots.AddSnapshot(baseline)
+
+		expected := "/me @user - " + ironmanAccount + " | Gained in the past week: 26,362,111 exp, 872 levels" +
+			" | Magic +10,156,589 exp (+46) | Ranged +5,866,885 exp (+40) | Strength +5,595,374 exp (+40)" +
+			" | Hitpoints +3,732,405 exp (+36) | Construction +451,646 exp (+15)"
+
+		if resp := say("week"); resp != expected {
+			t.Errorf("Said %s, but expected to say %s", resp, expected)
+		}
+	})
+
+	t.Run("UnknownStream", func(t *testing.T) {
+		if err := bot.HandleGainedLookup(channel, "user", "stream", ironmanAccount); err == nil {
+			t.Errorf("Expected failure without a join time, got success")
+		}
+	})
+}
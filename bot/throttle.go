@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle Allows an action at most once per key within a window
+type Throttle struct {
+	Window time.Duration
+
+	mutex sync.Mutex
+	last  map[string]time.Time
+}
+
+// throttleSweepSize Number of keys a Throttle holds before it drops keys whose
+// window has passed
+const throttleSweepSize = 256
+
+// NewThrottle Returns a Throttle with the given window
+func NewThrottle(window time.Duration) *Throttle {
+	return &Throttle{
+		Window: window,
+		last:   map[string]time.Time{},
+	}
+}
+
+// Allow Returns true and starts a new window for the key if its last window has
+// passed, false otherwise. A nil Throttle allows everything
+func (throttle *Throttle) Allow(key string) bool {
+	if throttle == nil {
+		return true
+	}
+
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+
+	now := time.Now()
+	if last, ok := throttle.last[key]; ok && now.Sub(last) < throttle.Window {
+		return false
+	}
+
+	if len(throttle.last) >= throttleSweepSize {
+		for k, last := range throttle.last {
+			if now.Sub(last) >= throttle.Window {
+				delete(throttle.last, k)
+			}
+		}
+	}
+
+	throttle.last[key] = now
+	return true
+}
@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	t.Run("Window", func(t *testing.T) {
+		throttle := NewThrottle(10 * time.Millisecond)
+
+		if !throttle.Allow("channel1") {
+			t.Fatalf("First action was throttled")
+		}
+
+		if throttle.Allow("channel1") {
+			t.Errorf("Second action within the window was allowed")
+		}
+
+		if !throttle.Allow("channel2") {
+			t.Errorf("Action for another key was throttled")
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		if !throttle.Allow("channel1") {
+			t.Errorf("Action after the window was throttled")
+		}
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		var throttle *Throttle
+
+		for i := 0; i < 2; i++ {
+			if !throttle.Allow("channel1") {
+				t.Errorf("Nil throttle throttled an action")
+			}
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -32,18 +33,40 @@ func main() {
 	session := session.New(dbConfig)
 	dbClient := dynamodb.New(session)
 
+	// Hiscore API configuration, caching responses so bursts of identical
+	// commands only reach the OSRS Hiscore API once, and backing off entirely
+	// while it's down
+	hiscoreAPI := bot.NewOSRSHiscoreAPI()
+	hiscoreAPI.Client = bot.NewCachingHiscoreAPIClient(
+		bot.NewCircuitBreakerHiscoreAPIClient(hiscoreAPI.Client, 10, 30*time.Second),
+		time.Minute,
+		1000,
+	)
+
 	oziachBot := bot.OziachBot{
 		TwitchClient: twitchClient,
 		ChannelDB: &bot.DynamoDBChannelDatabase{
 			Client: dbClient,
 		},
-		HiscoreAPI: bot.NewOSRSHiscoreAPI(),
+		HiscoreAPI:    hiscoreAPI,
+		OutageNotices: bot.NewThrottle(5 * time.Minute),
+		Snapshots: &bot.DynamoDBSnapshotStore{
+			Client: dbClient,
+		},
+		RecentSnapshots: bot.NewThrottle(15 * time.Minute),
+		JoinTimes:       bot.NewJoinTimes(),
+		Cooldowns:       bot.NewCommandCooldowns(),
+		Outbox:          bot.NewOutbox(twitchClient, bot.RateLimitWindow, 30*time.Second),
 	}
+	go oziachBot.Outbox.Run(nil)
 	go oziachBot.ServeAPI()
+	go oziachBot.ScheduleSnapshots(time.Hour, nil)
+	go bot.NewPoller(&oziachBot, 5*time.Minute, 5*time.Minute).Run(nil)
 
 	twitchClient.OnNewMessage(func(channel string, user twitch.User, message twitch.Message) {
 		go oziachBot.HandleMessage(channel, user, message)
 	})
+	twitchClient.OnNewUserstateMessage(oziachBot.Outbox.HandleUserstate)
 	err := oziachBot.InitBot()
 
 	if err != nil {
@@ -0,0 +1,77 @@
+// Package xp Converts between levels and experience in OSRS, including virtual
+// levels past 99
+package xp
+
+import "math"
+
+const (
+	// MinLevel Lowest level a skill can have
+	MinLevel = 1
+	// MaxRealLevel Highest level shown in game and on the hiscores
+	MaxRealLevel = 99
+	// MaxLevel Highest virtual level, the last one reachable before MaxExp
+	MaxLevel = 126
+	// MaxExp Experience cap of every skill
+	MaxExp = 200000000
+)
+
+// table Experience needed for each level, indexed by level
+var table = buildTable()
+
+// buildTable Computes the experience table with the formula used by the game:
+// each level costs floor(level + 300 * 2^(level / 7)) / 4 more experience than the
+// one before it, rounded down once summed
+func buildTable() [MaxLevel + 1]int {
+	var table [MaxLevel + 1]int
+	points := 0
+
+	for level := MinLevel + 1; level <= MaxLevel; level++ {
+		prev := float64(level - 1)
+		points += int(math.Floor(prev + 300*math.Pow(2, prev/7)))
+		table[level] = points / 4
+	}
+
+	return table
+}
+
+// ForLevel Returns the experience needed to reach the level, or -1 if the level is
+// out of the range [MinLevel, MaxLevel]
+func ForLevel(level int) int {
+	if level < MinLevel || level > MaxLevel {
+		return -1
+	}
+
+	return table[level]
+}
+
+// VirtualLevel Returns the level reached with the given experience, counting
+// virtual levels past 99
+func VirtualLevel(exp int) int {
+	level := MinLevel
+	for level < MaxLevel && exp >= table[level+1] {
+		level++
+	}
+
+	return level
+}
+
+// Level Returns the level reached with the given experience, capped at 99 like the
+// game and the hiscores do
+func Level(exp int) int {
+	if level := VirtualLevel(exp); level < MaxRealLevel {
+		return level
+	}
+
+	return MaxRealLevel
+}
+
+// ToNextLevel Returns the experience remaining until the virtual level after the one
+// reached with the given experience, or 0 if there isn't one
+func ToNextLevel(exp int) int {
+	level := VirtualLevel(exp)
+	if level >= MaxLevel {
+		return 0
+	}
+
+	return table[level+1] - exp
+}
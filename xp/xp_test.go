@@ -0,0 +1,69 @@
+package xp
+
+import "testing"
+
+func TestForLevel(t *testing.T) {
+	testCases := []struct {
+		Level    int
+		Expected int
+	}{
+		{1, 0},
+		{2, 83},
+		{50, 101333},
+		{92, 6517253},
+		{99, 13034431},
+		{120, 104273167},
+		{126, 188884740},
+		{0, -1},
+		{127, -1},
+	}
+
+	for _, tc := range testCases {
+		if actual := ForLevel(tc.Level); actual != tc.Expected {
+			t.Errorf("Level %d: expected %d exp, got %d", tc.Level, tc.Expected, actual)
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	testCases := []struct {
+		Exp     int
+		Level   int
+		Virtual int
+	}{
+		{0, 1, 1},
+		{82, 1, 1},
+		{83, 2, 2},
+		{13034430, 98, 98},
+		{13034431, 99, 99},
+		{14391160, 99, 100},
+		{MaxExp, 99, 126},
+	}
+
+	for _, tc := range testCases {
+		if actual := Level(tc.Exp); actual != tc.Level {
+			t.Errorf("%d exp: expected level %d, got %d", tc.Exp, tc.Level, actual)
+		}
+
+		if actual := VirtualLevel(tc.Exp); actual != tc.Virtual {
+			t.Errorf("%d exp: expected virtual level %d, got %d", tc.Exp, tc.Virtual, actual)
+		}
+	}
+}
+
+func TestToNextLevel(t *testing.T) {
+	testCases := []struct {
+		Exp      int
+		Expected int
+	}{
+		{0, 83},
+		{13034431, 14391160 - 13034431},
+		{MaxExp, 0},
+	}
+
+	for _, tc := range testCases {
+		if actual := ToNextLevel(tc.Exp); actual != tc.Expected {
+			t.Errorf("%d exp: expected %d exp to next level, got %d", tc.Exp, tc.Expected, actual)
+		}
+	}
+}